@@ -0,0 +1,264 @@
+package orm
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// ChunkCheckpoint is the position of ForEachChunk, all rows up to it have been processed.
+type ChunkCheckpoint struct {
+	// TableSuffix is the suffix of the sharded table, empty for not sharded model
+	TableSuffix string
+	// LastPk is the pk of the last processed row
+	LastPk interface{}
+}
+
+// ChunkOption is the option of ForEachChunk
+type ChunkOption func(*chunkOptions)
+
+type chunkOptions struct {
+	workers    int
+	suffixes   []string
+	resume     *ChunkCheckpoint
+	checkpoint func(ChunkCheckpoint) error
+}
+
+// ChunkWorkers process the chunks in n goroutines, the chunks are still read one by one.
+func ChunkWorkers(n int) ChunkOption {
+	return func(opts *chunkOptions) {
+		opts.workers = n
+	}
+}
+
+// ChunkShards walk all the sharded tables with suffixes in order, the suffixes of AllShards are used if not specified.
+func ChunkShards(suffixes ...string) ChunkOption {
+	return func(opts *chunkOptions) {
+		opts.suffixes = suffixes
+	}
+}
+
+// ChunkResume resume the walking after the checkpoint saved by previous job.
+func ChunkResume(cp ChunkCheckpoint) ChunkOption {
+	return func(opts *chunkOptions) {
+		opts.resume = &cp
+	}
+}
+
+// ChunkCheckpointFunc set the func to save the checkpoint after every chunk is processed.
+// With ChunkWorkers, the checkpoint only advances when all the chunks before it are processed.
+func ChunkCheckpointFunc(fn func(cp ChunkCheckpoint) error) ChunkOption {
+	return func(opts *chunkOptions) {
+		opts.checkpoint = fn
+	}
+}
+
+// chunk job of ForEachChunk
+type chunkJob struct {
+	seq   int
+	batch interface{}
+	cp    ChunkCheckpoint
+}
+
+// chunkTracker track the processed chunks, and save the checkpoint in order.
+type chunkTracker struct {
+	sync.Mutex
+	checkpoint func(ChunkCheckpoint) error
+	next       int
+	done       map[int]ChunkCheckpoint
+	err        error
+}
+
+// finish mark the chunk job as processed with err
+func (t *chunkTracker) finish(job *chunkJob, err error) {
+	t.Lock()
+	defer t.Unlock()
+
+	if t.err != nil {
+		return
+	}
+	if err != nil {
+		t.err = err
+		return
+	}
+
+	t.done[job.seq] = job.cp
+	for {
+		cp, ok := t.done[t.next]
+		if !ok {
+			return
+		}
+		delete(t.done, t.next)
+		t.next++
+		if t.checkpoint != nil {
+			if err = t.checkpoint(cp); err != nil {
+				t.err = err
+				return
+			}
+		}
+	}
+}
+
+// failed return the first error of chunk jobs
+func (t *chunkTracker) failed() error {
+	t.Lock()
+	defer t.Unlock()
+	return t.err
+}
+
+// ForEachChunk walk all rows by the pk in chunks of size, and call fn with every chunk.
+// nolint:gocyclo
+func (qs *querySetter) ForEachChunk(size int, fn func(batch interface{}) error, options ...ChunkOption) error {
+	if size <= 0 {
+		panic(fmt.Errorf("<QuerySeter.ForEachChunk> size must be positive, but got %d", size))
+	}
+
+	opts := &chunkOptions{workers: 1}
+	for _, option := range options {
+		option(opts)
+	}
+
+	suffixes := opts.suffixes
+	if len(suffixes) == 0 {
+		suffixes = qs.shards
+	}
+	if len(suffixes) == 0 {
+		tableSuffix, err := qs.getTableSuffix()
+		if err != nil {
+			return err
+		}
+		suffixes = []string{tableSuffix}
+	}
+
+	if opts.resume != nil {
+		found := false
+		for i, suffix := range suffixes {
+			if suffix == opts.resume.TableSuffix {
+				suffixes = suffixes[i:]
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("<QuerySeter.ForEachChunk> resume table suffix `%s` not found", opts.resume.TableSuffix)
+		}
+	}
+
+	tracker := &chunkTracker{
+		checkpoint: opts.checkpoint,
+		done:       make(map[int]ChunkCheckpoint),
+	}
+
+	var (
+		wg   sync.WaitGroup
+		jobs chan *chunkJob
+	)
+
+	if opts.workers > 1 {
+		jobs = make(chan *chunkJob)
+		for i := 0; i < opts.workers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for job := range jobs {
+					if tracker.failed() != nil {
+						continue
+					}
+					tracker.finish(job, fn(job.batch))
+				}
+			}()
+		}
+	}
+
+	seq := 0
+	err := func() error {
+		for i, suffix := range suffixes {
+			var lastPk interface{}
+			if i == 0 && opts.resume != nil {
+				lastPk = opts.resume.LastPk
+			}
+
+			for {
+				if err := tracker.failed(); err != nil {
+					return err
+				}
+
+				batch, pk, n, err := qs.readChunk(suffix, lastPk, size)
+				if err != nil {
+					return err
+				}
+				if n == 0 {
+					break
+				}
+				lastPk = pk
+
+				job := &chunkJob{
+					seq:   seq,
+					batch: batch,
+					cp:    ChunkCheckpoint{TableSuffix: suffix, LastPk: pk},
+				}
+				seq++
+
+				if jobs != nil {
+					jobs <- job
+				} else {
+					tracker.finish(job, fn(job.batch))
+				}
+
+				if n < size {
+					break
+				}
+			}
+		}
+		return nil
+	}()
+
+	if jobs != nil {
+		close(jobs)
+		wg.Wait()
+	}
+
+	if err != nil {
+		return err
+	}
+	return tracker.failed()
+}
+
+// readChunk read at most size rows after lastPk from the table with suffix, ordered by pk.
+// batch is a slice of model ptr, pk is the pk of the last row, n is the number of rows.
+func (qs *querySetter) readChunk(suffix string, lastPk interface{}, size int) (interface{}, interface{}, int, error) {
+	pk := qs.mi.fields.pk
+
+	chunkQs := *qs
+	chunkQs.tableSuffix = suffix
+	chunkQs.orders = []string{pk.column}
+	chunkQs.groups = nil
+	chunkQs.offset = 0
+	chunkQs.limit = size
+
+	if lastPk != nil {
+		seekCond := NewCondition().And(pk.name+ExprSep+"gt", lastPk)
+		if qs.cond != nil && !qs.cond.IsEmpty() {
+			seekCond = NewCondition().AndCond(qs.cond).AndCond(seekCond)
+		}
+		chunkQs.cond = seekCond
+	}
+
+	container := reflect.New(reflect.SliceOf(qs.mi.addrField.Type()))
+	db, err := chunkQs.routeDB()
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	if err = qs.mi.ReadBatch(qs.orm.ctx, db, &chunkQs, chunkQs.cond, container.Interface(), nil); err != nil {
+		return nil, nil, 0, err
+	}
+
+	slice := container.Elem()
+	n := slice.Len()
+	if n == 0 {
+		return nil, nil, 0, nil
+	}
+
+	last := slice.Index(n - 1).Elem()
+	return slice.Interface(), last.FieldByIndex(pk.fieldIndex).Interface(), n, nil
+}
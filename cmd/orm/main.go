@@ -0,0 +1,171 @@
+// Command orm run the schema commands of orm.RunCommand with the models of application.
+//
+// The models and migrations are registered in the init of application packages, so orm generate a small main
+// importing them, build and run it in the current module. For example:
+//	orm -pkg github.com/me/app/models -dsn "user:pass@tcp(127.0.0.1:3306)/app" syncdb -dry-run
+//	orm -pkg github.com/me/app/models -import github.com/lib/pq migrate up
+//	orm -pkg github.com/me/app/models -out ./cmd/ormtool
+//
+// The arguments after the flags of orm are passed to orm.RunCommand, see its usage by "orm -pkg ... help".
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// imports is the repeatable flag of import paths
+type imports []string
+
+func (i *imports) String() string {
+	return strings.Join(*i, ",")
+}
+
+func (i *imports) Set(value string) error {
+	for _, path := range strings.Split(value, ",") {
+		if path = strings.TrimSpace(path); path != "" {
+			*i = append(*i, path)
+		}
+	}
+	return nil
+}
+
+// values is the repeatable flag of values
+type values []string
+
+func (v *values) String() string {
+	return strings.Join(*v, ",")
+}
+
+func (v *values) Set(value string) error {
+	*v = append(*v, value)
+	return nil
+}
+
+var mainTemplate = template.Must(template.New("main").Parse(`// Code generated by cmd/orm. DO NOT EDIT.
+
+package main
+
+import (
+{{- range .}}
+	_ "{{.}}"
+{{- end}}
+
+	"github.com/std0d9k81/orm"
+)
+
+func main() {
+	orm.RunCommand()
+}
+`))
+
+// generateMain return the source of main importing the packages
+func generateMain(packages []string) ([]byte, error) {
+	packages = append([]string(nil), packages...)
+	sort.Strings(packages)
+
+	var buf bytes.Buffer
+	if err := mainTemplate.Execute(&buf, packages); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func main() {
+	var (
+		fs       = flag.NewFlagSet("orm", flag.ExitOnError)
+		packages imports
+		extra    imports
+		dsns     values
+		drivers  values
+		out      = fs.String("out", "", "write the main to the directory instead of running it")
+	)
+	fs.Var(&packages, "pkg", "the import path of package registering models and migrations, required, repeatable")
+	fs.Var(&extra, "import", "the import path of other packages, e.g. database driver, repeatable")
+	fs.Var(&dsns, "dsn", "the [name=]dsn of database passed to the command, repeatable")
+	fs.Var(&drivers, "driver", "the [name=]driver of database passed to the command, repeatable")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: orm -pkg path [-import path]... [-dsn [name=]dsn]... [-out dir] [command arguments]\n\n")
+		fs.PrintDefaults()
+	}
+	// nolint:errcheck
+	fs.Parse(os.Args[1:])
+
+	if len(packages) == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	src, err := generateMain(append(packages, extra...))
+	if err != nil {
+		exit(err)
+	}
+
+	if *out != "" {
+		if err = os.MkdirAll(*out, 0755); err != nil {
+			exit(err)
+		}
+		if err = ioutil.WriteFile(filepath.Join(*out, "main.go"), src, 0644); err != nil {
+			exit(err)
+		}
+		return
+	}
+
+	var args []string
+	for _, dsn := range dsns {
+		args = append(args, "-dsn", dsn)
+	}
+	for _, driver := range drivers {
+		args = append(args, "-driver", driver)
+	}
+	os.Exit(run(src, append(args, fs.Args()...)))
+}
+
+// run build the main in a temporary directory of the current module and run it with args, return the exit code
+func run(src []byte, args []string) int {
+	// the main must be in the module to import the packages of application
+	dir, err := ioutil.TempDir(".", "ormcmd")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "orm: %v\n", err)
+		return 1
+	}
+	// nolint:errcheck
+	defer os.RemoveAll(dir)
+
+	if err = ioutil.WriteFile(filepath.Join(dir, "main.go"), src, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "orm: %v\n", err)
+		return 1
+	}
+
+	bin := filepath.Join(dir, "orm")
+	build := exec.Command("go", "build", "-o", bin, "./"+filepath.ToSlash(dir))
+	build.Stdout, build.Stderr = os.Stderr, os.Stderr
+	if err = build.Run(); err != nil {
+		fmt.Fprintf(os.Stderr, "orm: build the command: %v\n", err)
+		return 1
+	}
+
+	cmd := exec.Command(bin, args...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err = cmd.Run(); err != nil {
+		if e, ok := err.(*exec.ExitError); ok {
+			return e.ExitCode()
+		}
+		fmt.Fprintf(os.Stderr, "orm: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func exit(err error) {
+	fmt.Fprintf(os.Stderr, "orm: %v\n", err)
+	os.Exit(1)
+}
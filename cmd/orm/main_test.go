@@ -0,0 +1,33 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateMain(t *testing.T) {
+	var packages imports
+	require.NoError(t, packages.Set("github.com/me/app/models, github.com/lib/pq"))
+	require.NoError(t, packages.Set("github.com/me/app/migrations"))
+
+	src, err := generateMain(packages)
+	require.NoError(t, err)
+	require.Equal(t, `// Code generated by cmd/orm. DO NOT EDIT.
+
+package main
+
+import (
+	_ "github.com/lib/pq"
+	_ "github.com/me/app/migrations"
+	_ "github.com/me/app/models"
+
+	"github.com/std0d9k81/orm"
+)
+
+func main() {
+	orm.RunCommand()
+}
+`, string(src))
+	require.Equal(t, "github.com/me/app/models", packages[0], "packages not sorted in place")
+}
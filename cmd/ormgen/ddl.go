@@ -0,0 +1,362 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// token of DDL
+type token struct {
+	text string
+	// the identifier quoted by backtick, or the string literal quoted by quote
+	quoted byte
+}
+
+// is return true if the token is the keyword or punctuation, case insensitive
+func (t token) is(word string) bool {
+	return t.quoted == 0 && strings.EqualFold(t.text, word)
+}
+
+// tokenize split the MySQL DDL into tokens, the comments are skipped
+// nolint:gocyclo
+func tokenize(src string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case unicode.IsSpace(rune(c)):
+			i++
+		case c == '#' || (c == '-' && strings.HasPrefix(src[i:], "-- ")) || strings.HasPrefix(src[i:], "--\n"):
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(src[i:], "/*"):
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated comment")
+			}
+			i += end + 4
+		case c == '`' || c == '\'' || c == '"':
+			var buf strings.Builder
+			j := i + 1
+			for ; j < len(src); j++ {
+				if src[j] == '\\' && c != '`' && j+1 < len(src) {
+					j++
+					buf.WriteByte(src[j])
+					continue
+				}
+				if src[j] == c {
+					// the doubled quote is escaped quote
+					if j+1 < len(src) && src[j+1] == c {
+						buf.WriteByte(c)
+						j++
+						continue
+					}
+					break
+				}
+				buf.WriteByte(src[j])
+			}
+			if j >= len(src) {
+				return nil, fmt.Errorf("unterminated quote %c", c)
+			}
+			tokens = append(tokens, token{text: buf.String(), quoted: c})
+			i = j + 1
+		case strings.IndexByte("(),;=", c) >= 0:
+			tokens = append(tokens, token{text: string(c)})
+			i++
+		default:
+			j := i
+			for j < len(src) && !unicode.IsSpace(rune(src[j])) && strings.IndexByte("(),;=`'\"", src[j]) < 0 {
+				j++
+			}
+			tokens = append(tokens, token{text: src[i:j]})
+			i = j
+		}
+	}
+	return tokens, nil
+}
+
+// parseDDL parse the CREATE TABLE statements of MySQL DDL, other statements are skipped.
+// "CREATE TABLE ... LIKE" copy the columns and indexes of the table created before.
+func parseDDL(src string) ([]*table, error) {
+	tokens, err := tokenize(src)
+	if err != nil {
+		return nil, err
+	}
+
+	tables := make(map[string]*table)
+	for len(tokens) > 0 {
+		end := 0
+		for end < len(tokens) && !tokens[end].is(";") {
+			end++
+		}
+		statement := tokens[:end]
+		if end < len(tokens) {
+			end++
+		}
+		tokens = tokens[end:]
+
+		if len(statement) < 2 || !statement[0].is("create") {
+			continue
+		}
+		p := &parser{tokens: statement[1:]}
+		p.accept("temporary")
+		if !p.accept("table") {
+			continue
+		}
+		t, err := p.parseCreateTable(tables)
+		if err != nil {
+			return nil, err
+		}
+		tables[t.name] = t
+	}
+
+	result := make([]*table, 0, len(tables))
+	for _, t := range tables {
+		result = append(result, t)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].name < result[j].name })
+	return result, nil
+}
+
+// parser of a DDL statement
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return token{}
+}
+
+func (p *parser) next() token {
+	t := p.peek()
+	p.pos++
+	return t
+}
+
+func (p *parser) eof() bool {
+	return p.pos >= len(p.tokens)
+}
+
+// accept consume the next tokens if they are the words
+func (p *parser) accept(words ...string) bool {
+	for i, word := range words {
+		if p.pos+i >= len(p.tokens) || !p.tokens[p.pos+i].is(word) {
+			return false
+		}
+	}
+	p.pos += len(words)
+	return true
+}
+
+func (p *parser) expect(word string) error {
+	if !p.accept(word) {
+		return fmt.Errorf("expect %s but got %q", word, p.peek().text)
+	}
+	return nil
+}
+
+// skipParens skip the tokens in parentheses, the parser is at the open parenthesis
+func (p *parser) skipParens() {
+	depth := 0
+	for !p.eof() {
+		t := p.next()
+		switch {
+		case t.is("("):
+			depth++
+		case t.is(")"):
+			depth--
+		}
+		if depth == 0 {
+			return
+		}
+	}
+}
+
+// parseName parse the name, the database of db.table is dropped
+func (p *parser) parseName() string {
+	name := p.next().text
+	if strings.HasPrefix(p.peek().text, ".") && p.peek().quoted == 0 {
+		// db.`table` or db.table
+		name = strings.TrimPrefix(p.next().text, ".")
+		if name == "" {
+			name = p.next().text
+		}
+	} else if i := strings.LastIndexByte(name, '.'); i >= 0 && p.tokens[p.pos-1].quoted == 0 {
+		name = name[i+1:]
+	}
+	return name
+}
+
+// parseCreateTable parse the statement after CREATE TABLE
+func (p *parser) parseCreateTable(tables map[string]*table) (*table, error) {
+	p.accept("if", "not", "exists")
+	t := &table{name: p.parseName()}
+
+	if p.accept("like") {
+		like, ok := tables[p.parseName()]
+		if !ok {
+			return nil, fmt.Errorf("table %s: like unknown table", t.name)
+		}
+		t.columns = like.columns
+		t.pk = like.pk
+		t.indexes = like.indexes
+		return t, nil
+	}
+
+	if err := p.expect("("); err != nil {
+		return nil, fmt.Errorf("table %s: %v", t.name, err)
+	}
+	for {
+		if err := p.parseDefinition(t); err != nil {
+			return nil, fmt.Errorf("table %s: %v", t.name, err)
+		}
+		if p.accept(",") {
+			continue
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, fmt.Errorf("table %s: %v", t.name, err)
+		}
+		break
+	}
+	return t, nil
+}
+
+// parseDefinition parse the definition of column, key or constraint
+func (p *parser) parseDefinition(t *table) error {
+	// CONSTRAINT [symbol] PRIMARY KEY | UNIQUE | FOREIGN KEY | CHECK
+	if p.accept("constraint") {
+		if t := p.peek(); !t.is("primary") && !t.is("unique") && !t.is("foreign") && !t.is("check") {
+			p.next()
+		}
+	}
+
+	switch {
+	case p.accept("primary", "key"):
+		columns, err := p.parseKeyColumns()
+		t.pk = columns
+		return err
+	case p.accept("unique"):
+		if !p.accept("key") {
+			p.accept("index")
+		}
+		return p.parseIndex(t, true)
+	case p.accept("key"), p.accept("index"):
+		return p.parseIndex(t, false)
+	case p.accept("fulltext"), p.accept("spatial"), p.accept("foreign"), p.accept("check"):
+		// not supported by model, skip to the end of definition
+		p.skipDefinition()
+		return nil
+	}
+	return p.parseColumn(t)
+}
+
+// skipDefinition skip to the comma or close parenthesis of the definition
+func (p *parser) skipDefinition() {
+	for !p.eof() && !p.peek().is(",") && !p.peek().is(")") {
+		if p.peek().is("(") {
+			p.skipParens()
+			continue
+		}
+		p.next()
+	}
+}
+
+// parseIndex parse the index after KEY or UNIQUE KEY
+func (p *parser) parseIndex(t *table, unique bool) error {
+	idx := &index{unique: unique}
+	if !p.peek().is("(") {
+		idx.name = p.next().text
+	}
+	columns, err := p.parseKeyColumns()
+	if err != nil {
+		return err
+	}
+	idx.columns = columns
+	t.indexes = append(t.indexes, idx)
+	p.skipDefinition()
+	return nil
+}
+
+// parseKeyColumns parse the columns of key in parentheses, the prefix length and order are dropped
+func (p *parser) parseKeyColumns() ([]string, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	var columns []string
+	for {
+		columns = append(columns, p.next().text)
+		for !p.eof() && !p.peek().is(",") && !p.peek().is(")") {
+			if p.peek().is("(") {
+				p.skipParens()
+				continue
+			}
+			p.next()
+		}
+		if p.accept(",") {
+			continue
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		return columns, nil
+	}
+}
+
+// parseColumn parse the column definition
+func (p *parser) parseColumn(t *table) error {
+	c := &column{name: p.next().text, nullable: true}
+	if c.name == "" {
+		return fmt.Errorf("missing column name")
+	}
+
+	typ := strings.ToLower(p.next().text)
+	if typ == "" {
+		return fmt.Errorf("column %s: missing type", c.name)
+	}
+	if p.peek().is("(") {
+		var args []string
+		p.next()
+		for !p.eof() && !p.peek().is(")") {
+			if t := p.next(); !t.is(",") {
+				args = append(args, t.text)
+			}
+		}
+		if err := p.expect(")"); err != nil {
+			return fmt.Errorf("column %s: %v", c.name, err)
+		}
+		typ += "(" + strings.Join(args, ",") + ")"
+	}
+	c.typ = typ
+
+	for !p.eof() && !p.peek().is(",") && !p.peek().is(")") {
+		switch {
+		case p.accept("unsigned"):
+			c.unsigned = true
+		case p.accept("not", "null"):
+			c.nullable = false
+		case p.accept("null"):
+			c.nullable = true
+		case p.accept("auto_increment"):
+			c.auto = true
+		case p.accept("primary", "key"), p.accept("key"):
+			t.pk = []string{c.name}
+		case p.accept("unique"):
+			p.accept("key")
+			t.indexes = append(t.indexes, &index{unique: true, columns: []string{c.name}})
+		case p.peek().is("("):
+			p.skipParens()
+		default:
+			p.next()
+		}
+	}
+
+	t.columns = append(t.columns, c)
+	return nil
+}
@@ -0,0 +1,349 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/std0d9k81/orm"
+)
+
+// options of generator
+type options struct {
+	// pkg the package name of generated file
+	pkg string
+	// prefix is trimmed from the table name to name the struct, e.g. t_user is User with prefix t_
+	prefix string
+	// initialisms are upper cased in the names, e.g. ID of user_id
+	initialisms map[string]bool
+	// shard merge the shard family, e.g. person_0 ~ person_3, into one struct with TableSuffix method
+	shard bool
+}
+
+// defaultInitialisms the common initialisms of golint
+const defaultInitialisms = "ACL,API,ASCII,CPU,CSS,DNS,EOF,GUID,HTML,HTTP,HTTPS,ID,IP,JSON,LHS,QPS,RAM,RHS,RPC," +
+	"SLA,SMTP,SQL,SSH,TCP,TLS,TTL,UDP,UI,UID,UUID,URI,URL,UTF8,VM,XML,XMPP,XSRF,XSS"
+
+func parseInitialisms(s string) map[string]bool {
+	initialisms := make(map[string]bool)
+	for _, word := range strings.Split(s, ",") {
+		if word = strings.TrimSpace(word); word != "" {
+			initialisms[strings.ToUpper(word)] = true
+		}
+	}
+	return initialisms
+}
+
+// camelName return the camel case name of snake name, e.g. UserID of user_id
+func (opts *options) camelName(name string) string {
+	var buf strings.Builder
+	for _, word := range strings.FieldsFunc(name, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+	}) {
+		if upper := strings.ToUpper(word); opts.initialisms[upper] {
+			buf.WriteString(upper)
+		} else {
+			buf.WriteString(upper[:1] + word[1:])
+		}
+	}
+
+	s := buf.String()
+	if s == "" || s[0] >= '0' && s[0] <= '9' {
+		s = "X" + s
+	}
+	return s
+}
+
+var shardRegexp = regexp.MustCompile(`^(.+)_(\d+)$`)
+
+// mergeShards merge the tables of the same columns named base_N into the table base,
+// the base table is kept if exists, only the families of 2 shards at least are merged.
+func mergeShards(tables []*table) []*table {
+	var (
+		byName   = make(map[string]*table, len(tables))
+		families = make(map[string][]*table)
+	)
+	for _, t := range tables {
+		byName[t.name] = t
+		if m := shardRegexp.FindStringSubmatch(t.name); m != nil {
+			families[m[1]] = append(families[m[1]], t)
+		}
+	}
+
+	merged := make(map[string]bool)
+	var result []*table
+	for base, shards := range families {
+		if len(shards) < 2 {
+			continue
+		}
+		first := shards[0]
+		same := true
+		for _, t := range shards[1:] {
+			same = same && sameColumns(first, t)
+		}
+		if t, ok := byName[base]; ok {
+			same = same && sameColumns(first, t)
+		}
+		if !same {
+			continue
+		}
+
+		sort.Slice(shards, func(i, j int) bool {
+			a, _ := strconv.Atoi(shardRegexp.FindStringSubmatch(shards[i].name)[2])
+			b, _ := strconv.Atoi(shardRegexp.FindStringSubmatch(shards[j].name)[2])
+			return a < b
+		})
+		family, ok := byName[base]
+		if !ok {
+			family = &table{name: base, columns: first.columns, pk: first.pk, indexes: first.indexes}
+			result = append(result, family)
+		}
+		for _, t := range shards {
+			family.shards = append(family.shards, t.name)
+			merged[t.name] = true
+		}
+	}
+
+	for _, t := range tables {
+		if !merged[t.name] {
+			result = append(result, t)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].name < result[j].name })
+	return result
+}
+
+// sameColumns return true if the tables have the same columns in order
+func sameColumns(a, b *table) bool {
+	if len(a.columns) != len(b.columns) {
+		return false
+	}
+	for i, c := range a.columns {
+		if *c != *b.columns[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// goType return the go type of column, and whether it is a json column
+// nolint:gocyclo
+func goType(c *column) (string, bool) {
+	typ := c.baseType()
+	if typ == "json" {
+		return "interface{}", true
+	}
+
+	var goTyp string
+	switch typ {
+	case "tinyint":
+		goTyp = "int8"
+		if c.typeArgs() == "1" && !c.unsigned {
+			goTyp = "bool"
+		}
+	case "bool", "boolean":
+		goTyp = "bool"
+	case "smallint", "year":
+		goTyp = "int16"
+	case "mediumint", "int", "integer":
+		goTyp = "int32"
+	case "bigint":
+		goTyp = "int64"
+	case "float":
+		goTyp = "float32"
+	case "double", "real", "decimal", "numeric":
+		goTyp = "float64"
+	case "datetime", "timestamp", "date":
+		goTyp = "time.Time"
+	case "binary", "varbinary", "tinyblob", "blob", "mediumblob", "longblob", "bit":
+		return "[]byte", false
+	default:
+		goTyp = "string"
+	}
+	if c.unsigned && strings.HasPrefix(goTyp, "int") {
+		goTyp = "u" + goTyp
+	}
+	return goTyp, false
+}
+
+// defaultColumnTypes the column types of go types in DDL of orm, the type tag is not needed for them
+var defaultColumnTypes = map[string]string{
+	"bool":        "tinyint(1)",
+	"int8":        "tinyint",
+	"int16":       "smallint",
+	"int32":       "int",
+	"int64":       "bigint",
+	"uint8":       "tinyint unsigned",
+	"uint16":      "smallint unsigned",
+	"uint32":      "int unsigned",
+	"uint64":      "bigint unsigned",
+	"float32":     "float",
+	"float64":     "double",
+	"time.Time":   "datetime",
+	"[]byte":      "longblob",
+	"interface{}": "longtext",
+}
+
+var intWidthRegexp = regexp.MustCompile(`^(tinyint|smallint|mediumint|int|bigint)\(\d+\)$`)
+
+// columnTag return the size, digits and decimals, or type tag of column, empty if the default column type of go type is the same
+func columnTag(c *column, goTyp string) string {
+	// the values of enum and set cannot be kept in the type tag, the column is generated as a string of default type
+	if base := c.baseType(); base == "enum" || base == "set" {
+		return ""
+	}
+	if goTyp == "string" && c.baseType() == "varchar" {
+		if size := c.typeArgs(); size != strconv.Itoa(orm.DefaultStringSize) {
+			return "size(" + size + ")"
+		}
+		return ""
+	}
+	if base := c.baseType(); (base == "decimal" || base == "numeric") && !c.unsigned {
+		if args := strings.Split(c.typeArgs(), ","); len(args) == 2 {
+			return "digits(" + strings.TrimSpace(args[0]) + ");decimals(" + strings.TrimSpace(args[1]) + ")"
+		}
+	}
+
+	typ := c.typ
+	if goTyp != "bool" {
+		typ = intWidthRegexp.ReplaceAllString(typ, "$1")
+	}
+	if c.unsigned {
+		typ += " unsigned"
+	}
+	if defaultColumnTypes[goTyp] == typ {
+		return ""
+	}
+	return "type(" + typ + ")"
+}
+
+// generate return the go source of models of tables
+// nolint:gocyclo
+func generate(tables []*table, opts *options, warn func(format string, args ...interface{})) ([]byte, error) {
+	if opts.shard {
+		tables = mergeShards(tables)
+	}
+
+	var (
+		body    bytes.Buffer
+		useTime bool
+	)
+	for _, t := range tables {
+		name := opts.camelName(strings.TrimPrefix(t.name, opts.prefix))
+
+		var pk string
+		switch len(t.pk) {
+		case 0:
+			warn("table %s: no primary key, the pk tag should be added by hand", t.name)
+		case 1:
+			pk = t.pk[0]
+		default:
+			warn("table %s: composite primary key %s is not supported, the pk tag should be added by hand",
+				t.name, strings.Join(t.pk, ","))
+		}
+
+		// the single column indexes are tags, others are TableIndex and TableUnique
+		singles := make(map[string]string)
+		var composites [2][][]string
+		for _, idx := range t.indexes {
+			switch {
+			case len(idx.columns) == 1 && idx.unique:
+				singles[idx.columns[0]] = "unique"
+			case len(idx.columns) == 1:
+				if _, ok := singles[idx.columns[0]]; !ok {
+					singles[idx.columns[0]] = "index"
+				}
+			case idx.unique:
+				composites[0] = append(composites[0], idx.columns)
+			default:
+				composites[1] = append(composites[1], idx.columns)
+			}
+		}
+
+		fields := make(map[string]string, len(t.columns))
+		used := make(map[string]bool, len(t.columns))
+		fmt.Fprintf(&body, "// %s is the model of table %s\n", name, t.name)
+		fmt.Fprintf(&body, "type %s struct {\n", name)
+		for _, c := range t.columns {
+			field := opts.camelName(c.name)
+			for i := 2; used[field]; i++ {
+				field = opts.camelName(c.name) + strconv.Itoa(i)
+			}
+			used[field] = true
+			fields[c.name] = field
+
+			goTyp, isJSON := goType(c)
+			if base := c.baseType(); base == "enum" || base == "set" {
+				warn("table %s: column %s is %s, the type tag should be added by hand to keep the values", t.name, c.name, base)
+			}
+			tags := []string{"column(" + c.name + ")"}
+			if c.name == pk {
+				tags = append(tags, "pk")
+			}
+			if c.auto {
+				tags = append(tags, "auto")
+			}
+			if isJSON {
+				tags = append(tags, "json")
+			}
+			if tag := columnTag(c, goTyp); tag != "" {
+				tags = append(tags, tag)
+			}
+			if tag, ok := singles[c.name]; ok && c.name != pk {
+				tags = append(tags, tag)
+			}
+
+			typ := goTyp
+			if c.nullable && c.name != pk && !isJSON && goTyp != "[]byte" {
+				typ = "*" + goTyp
+			}
+			useTime = useTime || goTyp == "time.Time"
+			fmt.Fprintf(&body, "\t%s %s `orm:\"%s\"`\n", field, typ, strings.Join(tags, ";"))
+		}
+		body.WriteString("}\n\n")
+
+		fmt.Fprintf(&body, "// TableName return the table name of %s\n", name)
+		fmt.Fprintf(&body, "func (m *%s) TableName() string {\n\treturn %q\n}\n\n", name, t.name)
+
+		for i, method := range []string{"TableUnique", "TableIndex"} {
+			if len(composites[i]) == 0 {
+				continue
+			}
+			fmt.Fprintf(&body, "// %s return the composite indexes of %s\n", method, name)
+			fmt.Fprintf(&body, "func (m *%s) %s() [][]string {\n\treturn [][]string{\n", name, method)
+			for _, columns := range composites[i] {
+				names := make([]string, len(columns))
+				for j, column := range columns {
+					names[j] = strconv.Quote(fields[column])
+				}
+				fmt.Fprintf(&body, "\t\t{%s},\n", strings.Join(names, ", "))
+			}
+			body.WriteString("\t}\n}\n\n")
+		}
+
+		if len(t.shards) > 0 {
+			fmt.Fprintf(&body, "// TableSuffix return the suffix of the shard table of %s, the shards are %s ~ %s\n",
+				name, t.shards[0], t.shards[len(t.shards)-1])
+			fmt.Fprintf(&body, "func (m *%s) TableSuffix() string {\n", name)
+			fmt.Fprintf(&body, "\t// TODO: compute the suffix of %d shards from the fields\n\treturn \"\"\n}\n\n", len(t.shards))
+		}
+	}
+
+	var src bytes.Buffer
+	src.WriteString("// Code generated by ormgen, the TableSuffix methods should be implemented by hand.\n\n")
+	fmt.Fprintf(&src, "package %s\n\n", opts.pkg)
+	if useTime {
+		src.WriteString("import \"time\"\n\n")
+	}
+	src.Write(body.Bytes())
+
+	out, err := format.Source(src.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("format the generated code: %v", err)
+	}
+	return out, nil
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDDL(t *testing.T) {
+	src, err := ioutil.ReadFile("../../tests/db.sql")
+	require.NoError(t, err)
+
+	tables, err := parseDDL(string(src))
+	require.NoError(t, err)
+
+	names := make([]string, len(tables))
+	for i, t := range tables {
+		names[i] = t.name
+	}
+	require.Equal(t, []string{"any_obj", "dynamic_test", "json_test", "json_test2",
+		"person", "person_0", "person_1", "person_2", "person_3", "time_obj"}, names)
+
+	person := tables[4]
+	require.Equal(t, []string{"id"}, person.pk)
+	require.Equal(t, &column{name: "id", typ: "int", unsigned: true, auto: true}, person.columns[0])
+	require.Equal(t, &column{name: "name", typ: "varchar(255)"}, person.columns[2])
+	require.Equal(t, person.columns, tables[5].columns, "create table like")
+
+	tables, err = parseDDL("CREATE TABLE `db`.`order` (\n" +
+		"  `id` bigint(20) NOT NULL AUTO_INCREMENT COMMENT 'the id, pk',\n" +
+		"  `user_id` bigint(20) unsigned NOT NULL DEFAULT '0',\n" +
+		"  `price` decimal(10,2) DEFAULT NULL,\n" +
+		"  `status` enum('new','paid') NOT NULL DEFAULT 'new',\n" +
+		"  `code` char(16) NOT NULL UNIQUE,\n" +
+		"  `created` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,\n" +
+		"  /* the payload */ `extra` json,\n" +
+		"  PRIMARY KEY (`id`),\n" +
+		"  UNIQUE KEY `uniq_user` (`user_id`, `created`),\n" +
+		"  KEY `idx_status` (`status`(4)) USING BTREE,\n" +
+		"  CONSTRAINT `fk_user` FOREIGN KEY (`user_id`) REFERENCES `user` (`id`)\n" +
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;")
+	require.NoError(t, err)
+	require.Len(t, tables, 1)
+	order := tables[0]
+	require.Equal(t, "order", order.name)
+	require.Equal(t, []*column{
+		{name: "id", typ: "bigint(20)", auto: true},
+		{name: "user_id", typ: "bigint(20)", unsigned: true},
+		{name: "price", typ: "decimal(10,2)", nullable: true},
+		{name: "status", typ: "enum(new,paid)"},
+		{name: "code", typ: "char(16)"},
+		{name: "created", typ: "datetime"},
+		{name: "extra", typ: "json", nullable: true},
+	}, order.columns)
+	require.Equal(t, []*index{
+		{unique: true, columns: []string{"code"}},
+		{name: "uniq_user", unique: true, columns: []string{"user_id", "created"}},
+		{name: "idx_status", columns: []string{"status"}},
+	}, order.indexes)
+
+	_, err = parseDDL("CREATE TABLE t2 LIKE t1;")
+	require.Error(t, err)
+	_, err = parseDDL("CREATE TABLE t (id int;")
+	require.Error(t, err)
+}
+
+func TestGenerate(t *testing.T) {
+	src, err := ioutil.ReadFile("../../tests/db.sql")
+	require.NoError(t, err)
+	tables, err := parseDDL(string(src))
+	require.NoError(t, err)
+
+	opts := &options{pkg: "models", initialisms: parseInitialisms(defaultInitialisms), shard: true}
+	out, err := generate(tables, opts, func(string, ...interface{}) {})
+	require.NoError(t, err)
+
+	code := string(out)
+	require.Contains(t, code, "package models\n\nimport \"time\"\n")
+	require.Contains(t, code, "type Person struct {\n"+
+		"\tID       uint32 `orm:\"column(id);pk;auto\"`\n"+
+		"\tPersonID uint32 `orm:\"column(person_id)\"`\n"+
+		"\tName     string `orm:\"column(name)\"`\n"+
+		"\tAge      int32  `orm:\"column(age)\"`\n"+
+		"}\n")
+	require.Contains(t, code, "func (m *Person) TableName() string {\n\treturn \"person\"\n}\n")
+	require.Contains(t, code, "the shards are person_0 ~ person_3\nfunc (m *Person) TableSuffix() string {")
+	require.NotContains(t, code, "Person0")
+	require.Contains(t, code, "\tContent    string `orm:\"column(content);size(1024)\"`\n")
+	require.Contains(t, code, "type JSONTest2 struct {")
+	require.Contains(t, code, "\tContent string `orm:\"column(content);type(text)\"`\n")
+	require.Contains(t, code, "\tObjTime time.Time `orm:\"column(obj_time);type(timestamp)\"`\n")
+
+	tables, err = parseDDL("CREATE TABLE t_order (\n" +
+		"  id bigint NOT NULL AUTO_INCREMENT,\n" +
+		"  user_id bigint NOT NULL,\n" +
+		"  extra json,\n" +
+		"  enabled tinyint(1) NOT NULL,\n" +
+		"  note varchar(255),\n" +
+		"  price decimal(10, 2) NOT NULL,\n" +
+		"  status enum('new','paid') NOT NULL,\n" +
+		"  PRIMARY KEY (id),\n" +
+		"  KEY idx_user (user_id, enabled)\n" +
+		");\n" +
+		"CREATE TABLE t_log_1 (id int);\n")
+	require.NoError(t, err)
+
+	var warnings []string
+	opts.prefix = "t_"
+	out, err = generate(tables, opts, func(format string, args ...interface{}) { warnings = append(warnings, format) })
+	require.NoError(t, err)
+
+	code = string(out)
+	require.Contains(t, code, "type Order struct {\n"+
+		"\tID      int64       `orm:\"column(id);pk;auto\"`\n"+
+		"\tUserID  int64       `orm:\"column(user_id)\"`\n"+
+		"\tExtra   interface{} `orm:\"column(extra);json;type(json)\"`\n"+
+		"\tEnabled bool        `orm:\"column(enabled)\"`\n"+
+		"\tNote    *string     `orm:\"column(note)\"`\n"+
+		"\tPrice   float64     `orm:\"column(price);digits(10);decimals(2)\"`\n"+
+		"\tStatus  string      `orm:\"column(status)\"`\n"+
+		"}\n")
+	require.Contains(t, code, "\treturn \"t_order\"\n")
+	require.Contains(t, code, "func (m *Order) TableIndex() [][]string {\n\treturn [][]string{\n\t\t{\"UserID\", \"Enabled\"},\n\t}\n}\n")
+	require.Contains(t, code, "type Log1 struct {", "single table is not a shard family")
+	require.NotContains(t, code, "import")
+	require.Len(t, warnings, 2, "enum column of t_order, no primary key of t_log_1")
+}
+
+func TestCamelName(t *testing.T) {
+	opts := &options{initialisms: parseInitialisms("ID,URL")}
+	for name, expected := range map[string]string{
+		"user_id":    "UserID",
+		"avatar_url": "AvatarURL",
+		"id":         "ID",
+		"Name":       "Name",
+		"2fa":        "X2fa",
+		"obj-omit":   "ObjOmit",
+		"createdAt":  "CreatedAt",
+	} {
+		require.Equal(t, expected, opts.camelName(name), name)
+	}
+}
@@ -0,0 +1,112 @@
+// Command ormgen generate the model structs of orm from the schema of MySQL database or DDL file.
+//
+// Usage:
+//	ormgen -dsn "user:pass@tcp(127.0.0.1:3306)/orm_test" -pkg models -out models/models.go
+//	ormgen -ddl tests/db.sql -pkg models -tables person,json_test
+//
+// The columns are mapped by the orm tags column, pk, auto, json, size, type, index and unique,
+// the composite indexes are mapped by the TableIndex and TableUnique methods.
+// The tables of the same columns named base_N, e.g. person_0 ~ person_3, are merged into one struct
+// with a TableSuffix method stub, which should be implemented by hand.
+package main
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	_ "github.com/go-sql-driver/mysql"
+)
+
+func main() {
+	var (
+		dsn         = flag.String("dsn", "", "the data source name of MySQL, the same as orm.RegisterDB")
+		ddl         = flag.String("ddl", "", "the DDL file of CREATE TABLE statements, used if dsn is empty")
+		pkg         = flag.String("pkg", "models", "the package name of generated file")
+		out         = flag.String("out", "", "the generated file, stdout if empty")
+		tables      = flag.String("tables", "", "the comma separated tables to generate, all tables if empty")
+		prefix      = flag.String("prefix", "", "the prefix trimmed from table name to name the struct")
+		initialisms = flag.String("initialisms", defaultInitialisms, "the comma separated initialisms upper cased in names")
+		shard       = flag.Bool("shard", true, "merge the tables named base_N into one struct with TableSuffix method")
+	)
+	flag.Parse()
+
+	if err := run(*dsn, *ddl, *out, *tables, &options{
+		pkg:         *pkg,
+		prefix:      *prefix,
+		initialisms: parseInitialisms(*initialisms),
+		shard:       *shard,
+	}); err != nil {
+		fmt.Fprintf(os.Stderr, "ormgen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(dsn, ddl, out, names string, opts *options) error {
+	var (
+		tables []*table
+		err    error
+	)
+	switch {
+	case dsn != "":
+		db, err := sql.Open("mysql", dsn)
+		if err != nil {
+			return err
+		}
+		// nolint:errcheck
+		defer db.Close()
+		if tables, err = loadTables(db); err != nil {
+			return err
+		}
+	case ddl != "":
+		src, err := ioutil.ReadFile(ddl)
+		if err != nil {
+			return err
+		}
+		if tables, err = parseDDL(string(src)); err != nil {
+			return fmt.Errorf("%s: %v", ddl, err)
+		}
+	default:
+		return fmt.Errorf("dsn or ddl is required")
+	}
+
+	if names != "" {
+		tables = filterTables(tables, strings.Split(names, ","))
+	}
+
+	src, err := generate(tables, opts, func(format string, args ...interface{}) {
+		fmt.Fprintf(os.Stderr, "ormgen: "+format+"\n", args...)
+	})
+	if err != nil {
+		return err
+	}
+
+	if out == "" {
+		_, err = os.Stdout.Write(src)
+		return err
+	}
+	return ioutil.WriteFile(out, src, 0644)
+}
+
+// filterTables return the tables with names, the shards of the tables are kept too
+func filterTables(tables []*table, names []string) []*table {
+	wanted := make(map[string]bool, len(names))
+	for _, name := range names {
+		wanted[strings.TrimSpace(name)] = true
+	}
+
+	var result []*table
+	for _, t := range tables {
+		if wanted[t.name] {
+			result = append(result, t)
+			continue
+		}
+		if m := shardRegexp.FindStringSubmatch(t.name); m != nil && wanted[m[1]] {
+			result = append(result, t)
+		}
+	}
+	return result
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"database/sql"
+	"sort"
+	"strings"
+)
+
+// table is the schema of a table, parsed from DDL or read from database
+type table struct {
+	name    string
+	columns []*column
+	pk      []string
+	indexes []*index
+	// the tables of shard family, e.g. person_0 ~ person_3 of person
+	shards []string
+}
+
+// column is the schema of a column
+type column struct {
+	name string
+	// typ is the lower case type without attributes, e.g. int, varchar(255), decimal(10,2)
+	typ      string
+	unsigned bool
+	nullable bool
+	auto     bool
+}
+
+// index is the index of a table, the primary key is not included
+type index struct {
+	name    string
+	unique  bool
+	columns []string
+}
+
+// baseType return the type name without arguments, e.g. varchar of varchar(255)
+func (c *column) baseType() string {
+	if i := strings.IndexByte(c.typ, '('); i >= 0 {
+		return c.typ[:i]
+	}
+	return c.typ
+}
+
+// typeArgs return the arguments of type, e.g. 255 of varchar(255)
+func (c *column) typeArgs() string {
+	i, j := strings.IndexByte(c.typ, '('), strings.LastIndexByte(c.typ, ')')
+	if i < 0 || j < i {
+		return ""
+	}
+	return c.typ[i+1 : j]
+}
+
+func (t *table) getColumn(name string) *column {
+	for _, c := range t.columns {
+		if strings.EqualFold(c.name, name) {
+			return c
+		}
+	}
+	return nil
+}
+
+// loadTables read the schema of tables in the current database of MySQL
+func loadTables(db *sql.DB) ([]*table, error) {
+	tables := make(map[string]*table)
+
+	rows, err := db.Query("SELECT table_name, column_name, column_type, is_nullable, column_key, extra " +
+		"FROM information_schema.columns WHERE table_schema = DATABASE() ORDER BY table_name, ordinal_position")
+	if err != nil {
+		return nil, err
+	}
+	// nolint:errcheck
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			tableName, columnType, nullable, key, extra string
+			c                                           = &column{}
+		)
+		if err = rows.Scan(&tableName, &c.name, &columnType, &nullable, &key, &extra); err != nil {
+			return nil, err
+		}
+
+		t, ok := tables[tableName]
+		if !ok {
+			t = &table{name: tableName}
+			tables[tableName] = t
+		}
+
+		columnType = strings.ToLower(columnType)
+		c.unsigned = strings.Contains(columnType, " unsigned")
+		c.typ = strings.Fields(columnType)[0]
+		c.nullable = nullable == "YES"
+		c.auto = strings.Contains(strings.ToLower(extra), "auto_increment")
+		if key == "PRI" {
+			t.pk = append(t.pk, c.name)
+		}
+		t.columns = append(t.columns, c)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err = loadIndexes(db, tables); err != nil {
+		return nil, err
+	}
+
+	result := make([]*table, 0, len(tables))
+	for _, t := range tables {
+		result = append(result, t)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].name < result[j].name })
+	return result, nil
+}
+
+// loadIndexes read the indexes of tables, the primary key is skipped
+func loadIndexes(db *sql.DB, tables map[string]*table) error {
+	rows, err := db.Query("SELECT table_name, index_name, non_unique, column_name FROM information_schema.statistics " +
+		"WHERE table_schema = DATABASE() ORDER BY table_name, index_name, seq_in_index")
+	if err != nil {
+		return err
+	}
+	// nolint:errcheck
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			tableName, indexName, columnName string
+			nonUnique                        int
+		)
+		if err = rows.Scan(&tableName, &indexName, &nonUnique, &columnName); err != nil {
+			return err
+		}
+
+		t, ok := tables[tableName]
+		if !ok || indexName == "PRIMARY" {
+			continue
+		}
+		if n := len(t.indexes); n == 0 || t.indexes[n-1].name != indexName {
+			t.indexes = append(t.indexes, &index{name: indexName, unique: nonUnique == 0})
+		}
+		last := t.indexes[len(t.indexes)-1]
+		last.columns = append(last.columns, columnName)
+	}
+	return rows.Err()
+}
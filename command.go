@@ -0,0 +1,506 @@
+package orm
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const commandUsage = `Usage: %s [-dsn [name=]dsn]... [-driver [name=]driver]... command [arguments]
+
+The databases are registered by the flags, or the environment variables ORM_DSN and ORM_DRIVER
+of the default database, ORM_DSN_<NAME> and ORM_DRIVER_<NAME> of others, e.g. ORM_DSN_ORM_TEST2.
+The name of dsn can be omitted for the default database, the driver is mysql by default.
+The flags take precedence over the environment variables. The database registered by the application
+is used as is, it's an error to give another dsn or driver of it.
+
+The commands are:
+	syncdb [-db name] [-force] [-dry-run] [-v]     create the tables of models
+	sqlall                                         print the DDL of all models
+	migrate up [-db name] [-dry-run]               apply the pending migrations
+	migrate down [-db name] [-n 1] [-dry-run]      revert the last n migrations
+	migrate redo [-db name]                        revert and apply the last migration
+	migrate status [-db name]                      print the status of migrations
+	check-schema [-db name]                        report the drift of models and tables
+	shards create -model name [-suffixes a,b] [-ahead n] [-retention n] [-drop] [-dry-run]
+	                                               create the missing shard tables of model
+`
+
+// RunCommand run the schema command of os.Args and exit, the models and migrations must be registered before.
+// it's the main of the command generated by cmd/orm, for example:
+//	import (
+//		_ "github.com/me/app/models"
+//		"github.com/std0d9k81/orm"
+//	)
+//
+//	func main() {
+//		orm.RunCommand()
+//	}
+func RunCommand() {
+	os.Exit(runCommand(os.Args[0], os.Args[1:], os.Environ(), os.Stdout, os.Stderr))
+}
+
+// runCommand run the command of args, return the exit code, 1 for error and 2 for wrong usage
+func runCommand(program string, args, environ []string, stdout, stderr io.Writer) (code int) {
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Fprintf(stderr, "%v\n", r)
+			code = 1
+		}
+	}()
+
+	var (
+		fs      = flag.NewFlagSet(program, flag.ContinueOnError)
+		dsns    commandFlags
+		drivers commandFlags
+	)
+	fs.SetOutput(stderr)
+	fs.Usage = func() { fmt.Fprintf(stderr, commandUsage, program) }
+	fs.Var(&dsns, "dsn", "the data source of database")
+	fs.Var(&drivers, "driver", "the driver of database")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	dbs, err := parseCommandDBs(dsns, drivers, environ)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	for _, name := range sortedKeys(dbs) {
+		// the database registered by the models package is not overridden, the command must not run on another one
+		if db, ok := dbCache.get(name); ok {
+			if db.Driver != dbs[name][0] || db.DataSource != dbs[name][1] {
+				fmt.Fprintf(stderr, "database %s is registered by the application with another dsn or driver, "+
+					"remove the -dsn and ORM_DSN* of it\n", name)
+				return 2
+			}
+			continue
+		}
+		RegisterDB(name, dbs[name][0], dbs[name][1])
+	}
+
+	cmd := &command{stdout: stdout, stderr: stderr}
+	name, args := fs.Arg(0), fs.Args()[1:]
+	if (name == "migrate" || name == "shards") && len(args) > 0 {
+		name, args = name+" "+args[0], args[1:]
+	}
+
+	var run func(args []string) error
+	switch name {
+	case "help":
+		fmt.Fprintf(stdout, commandUsage, program)
+		return 0
+	case "syncdb":
+		run = cmd.syncDB
+	case "sqlall":
+		run = cmd.sqlAll
+	case "migrate up", "migrate down", "migrate redo", "migrate status":
+		run = cmd.migrate
+		args = append([]string{strings.TrimPrefix(name, "migrate ")}, args...)
+	case "check-schema":
+		run = cmd.checkSchema
+	case "shards create":
+		run = cmd.createShards
+	default:
+		fmt.Fprintf(stderr, "unknown command %q\n", name)
+		fs.Usage()
+		return 2
+	}
+
+	if err = run(args); err == errCommandUsage {
+		return 2
+	} else if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	return cmd.code
+}
+
+// errCommandUsage indicates the wrong arguments of command, the usage has been printed
+var errCommandUsage = fmt.Errorf("wrong usage")
+
+// commandFlags is the repeatable flag
+type commandFlags []string
+
+func (f *commandFlags) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *commandFlags) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+var commandNameRegexp = regexp.MustCompile(`^([A-Za-z0-9_]+)=(.*)$`)
+
+// parseCommandDBs return the driver and dsn of databases by name from the flags and environment variables,
+// the flags take precedence over the environment variables.
+func parseCommandDBs(dsns, drivers, environ []string) (map[string][2]string, error) {
+	var (
+		dsnByName    = make(map[string]string)
+		driverByName = make(map[string]string)
+		driver       = "mysql"
+	)
+
+	for _, env := range environ {
+		kv := strings.SplitN(env, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		switch key := kv[0]; {
+		case key == "ORM_DSN":
+			dsnByName["default"] = kv[1]
+		case key == "ORM_DRIVER":
+			driver = kv[1]
+		case strings.HasPrefix(key, "ORM_DSN_"):
+			dsnByName[strings.ToLower(strings.TrimPrefix(key, "ORM_DSN_"))] = kv[1]
+		case strings.HasPrefix(key, "ORM_DRIVER_"):
+			driverByName[strings.ToLower(strings.TrimPrefix(key, "ORM_DRIVER_"))] = kv[1]
+		}
+	}
+
+	for _, dsn := range dsns {
+		if m := commandNameRegexp.FindStringSubmatch(dsn); m != nil {
+			dsnByName[m[1]] = m[2]
+		} else {
+			dsnByName["default"] = dsn
+		}
+	}
+	for _, d := range drivers {
+		if m := commandNameRegexp.FindStringSubmatch(d); m != nil {
+			driverByName[m[1]] = m[2]
+		} else {
+			driver = d
+		}
+	}
+
+	dbs := make(map[string][2]string, len(dsnByName))
+	for name, dsn := range dsnByName {
+		if dsn == "" {
+			return nil, fmt.Errorf("empty dsn of database %s", name)
+		}
+		d, ok := driverByName[name]
+		if !ok {
+			d = driver
+		}
+		dbs[name] = [2]string{d, dsn}
+	}
+	for name := range driverByName {
+		if _, ok := dbs[name]; !ok {
+			return nil, fmt.Errorf("driver of database %s without dsn", name)
+		}
+	}
+	return dbs, nil
+}
+
+func sortedKeys(m map[string][2]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// command run the schema commands
+type command struct {
+	stdout io.Writer
+	stderr io.Writer
+	// exit code of the command succeeded, e.g. 1 if schema drift is found
+	code int
+}
+
+// newFlagSet return the flag set of command
+func (cmd *command) newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(cmd.stderr)
+	return fs
+}
+
+// parse parse the arguments, no positional argument is allowed
+func (cmd *command) parse(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		// the error and usage have been printed by flag set
+		return errCommandUsage
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(cmd.stderr, "unexpected arguments %v of %s\n", fs.Args(), fs.Name())
+		fs.Usage()
+		return errCommandUsage
+	}
+	return nil
+}
+
+func (cmd *command) syncDB(args []string) error {
+	var (
+		fs      = cmd.newFlagSet("syncdb")
+		dbName  = fs.String("db", "default", "the database to sync")
+		force   = fs.Bool("force", false, "drop and recreate the existing tables")
+		dryRun  = fs.Bool("dry-run", false, "print the DDL without executing")
+		verbose = fs.Bool("v", false, "print the DDL executed")
+	)
+	if err := cmd.parse(fs, args); err != nil {
+		return err
+	}
+
+	var out io.Writer
+	if *verbose || *dryRun {
+		out = cmd.stdout
+	}
+	return syncDB(*dbName, *force, *dryRun, out)
+}
+
+func (cmd *command) sqlAll(args []string) error {
+	if err := cmd.parse(cmd.newFlagSet("sqlall"), args); err != nil {
+		return err
+	}
+	BootStrap()
+	_, err := io.WriteString(cmd.stdout, SQLAll())
+	return err
+}
+
+// nolint:gocyclo
+func (cmd *command) migrate(args []string) error {
+	var (
+		action = args[0]
+		fs     = cmd.newFlagSet("migrate " + action)
+		dbName = fs.String("db", "default", "the database to migrate")
+		n      = fs.Int("n", 1, "the number of migrations to revert")
+		dryRun = fs.Bool("dry-run", false, "print the migrations without running")
+	)
+	if err := cmd.parse(fs, args[1:]); err != nil {
+		return err
+	}
+
+	BootStrap()
+	m := NewMigrator(*dbName, defaultLogger)
+
+	if *dryRun {
+		if action != "up" && action != "down" {
+			fmt.Fprintf(cmd.stderr, "-dry-run is only supported by migrate up and down\n")
+			return errCommandUsage
+		}
+		statuses, err := m.Status()
+		if err != nil {
+			return err
+		}
+		cmd.printMigrations(action, statuses, *n)
+		return nil
+	}
+
+	switch action {
+	case "up":
+		names, err := m.Up()
+		for _, name := range names {
+			fmt.Fprintf(cmd.stdout, "applied %s\n", name)
+		}
+		return err
+	case "down":
+		names, err := m.Down(*n)
+		for _, name := range names {
+			fmt.Fprintf(cmd.stdout, "reverted %s\n", name)
+		}
+		return err
+	case "redo":
+		name, err := m.Redo()
+		if name != "" {
+			fmt.Fprintf(cmd.stdout, "redone %s\n", name)
+		}
+		return err
+	}
+
+	statuses, err := m.Status()
+	if err != nil {
+		return err
+	}
+	for _, status := range statuses {
+		state, appliedAt := "pending", ""
+		switch {
+		case status.Missing:
+			state = "missing"
+		case status.Modified:
+			state = "modified"
+		case status.Applied:
+			state = "applied"
+		}
+		if status.Applied {
+			appliedAt = status.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+		fmt.Fprintf(cmd.stdout, "%-10s %-19s %s\n", state, appliedAt, status.Name)
+	}
+	return nil
+}
+
+// printMigrations print the statements of migrations would be applied or reverted
+func (cmd *command) printMigrations(action string, statuses []*MigrationStatus, n int) {
+	var names []string
+	if action == "up" {
+		for _, status := range statuses {
+			if !status.Applied {
+				names = append(names, status.Name)
+			}
+		}
+	} else {
+		// the same as Down, the last applied in order of applied time, including the missing migrations
+		applied := make(map[string]*appliedMigration)
+		for _, status := range statuses {
+			if status.Applied {
+				applied[status.Name] = &appliedMigration{name: status.Name, appliedAt: status.AppliedAt}
+			}
+		}
+		names = lastApplied(applied, n)
+	}
+
+	for _, name := range names {
+		fmt.Fprintf(cmd.stdout, "-- %s %s\n", action, name)
+		m := getMigration(name)
+		if m == nil {
+			fmt.Fprintf(cmd.stdout, "-- not registered\n")
+			continue
+		}
+		if m.checksum == "" {
+			fmt.Fprintf(cmd.stdout, "-- migration written in Go\n")
+			continue
+		}
+
+		statements := m.upSQL
+		if action == "down" {
+			statements = m.downSQL
+		}
+		for _, statement := range statements {
+			fmt.Fprintf(cmd.stdout, "%s;\n", statement)
+		}
+	}
+}
+
+func (cmd *command) checkSchema(args []string) error {
+	var (
+		fs     = cmd.newFlagSet("check-schema")
+		dbName = fs.String("db", "", "the database to check, all databases of models if empty")
+	)
+	if err := cmd.parse(fs, args); err != nil {
+		return err
+	}
+
+	BootStrap()
+	dbNames := []string{*dbName}
+	if *dbName == "" {
+		dbNames = getModelDBs()
+	}
+
+	for _, name := range dbNames {
+		report, err := CheckSchema(name)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.stdout, "database %s:", name)
+		if !report.HasDrift() {
+			fmt.Fprintf(cmd.stdout, " no schema drift\n")
+			continue
+		}
+		fmt.Fprintf(cmd.stdout, " %d schema drifts\n", len(report.Drifts))
+		for _, drift := range report.Drifts {
+			fmt.Fprintf(cmd.stdout, "\t%s\n", drift)
+		}
+		cmd.code = 1
+	}
+	return nil
+}
+
+func (cmd *command) createShards(args []string) error {
+	var (
+		fs        = cmd.newFlagSet("shards create")
+		model     = fs.String("model", "", "the sharded model, the full name, struct name or table name")
+		suffixes  = fs.String("suffixes", "", "the comma separated suffixes of shards, the suffixes of strategy if empty")
+		ahead     = fs.Int("ahead", 0, "the number of future periods to create for the model sharded by time")
+		retention = fs.Int("retention", 0, "the number of recent periods to keep for the model sharded by time")
+		drop      = fs.Bool("drop", false, "drop the shards out of retention")
+		dryRun    = fs.Bool("dry-run", false, "print the DDL without executing")
+	)
+	if err := cmd.parse(fs, args); err != nil {
+		return err
+	}
+	if *model == "" {
+		fmt.Fprintf(cmd.stderr, "-model is required\n")
+		fs.Usage()
+		return errCommandUsage
+	}
+
+	BootStrap()
+	mi, err := findModel(*model)
+	if err != nil {
+		return err
+	}
+
+	options := []ProvisionOption{ProvisionAhead(*ahead)}
+	if *suffixes != "" {
+		options = append(options, ProvisionSuffixes(strings.Split(*suffixes, ",")...))
+	}
+	if *retention > 0 {
+		options = append(options, ProvisionRetention(*retention, *drop))
+	}
+	if *dryRun {
+		options = append(options, ProvisionDryRun(cmd.stdout))
+	}
+
+	result, err := provisionShards(mi, options)
+	if result != nil {
+		created, dropped := "created", "dropped"
+		if *dryRun {
+			created, dropped = "to create", "to drop"
+		}
+		for _, item := range []struct {
+			action   string
+			suffixes []string
+		}{{created, result.Created}, {"expired", result.Expired}, {dropped, result.Dropped}} {
+			for _, suffix := range item.suffixes {
+				fmt.Fprintf(cmd.stdout, "-- %s %s\n", item.action, mi.getTableBySuffix(suffix))
+			}
+		}
+	}
+	return err
+}
+
+// getModelDBs return the databases of models in order
+func getModelDBs() []string {
+	var dbNames []string
+	seen := make(map[string]bool)
+	for _, mi := range getSortedModels("") {
+		if !seen[mi.db] {
+			seen[mi.db] = true
+			dbNames = append(dbNames, mi.db)
+		}
+	}
+	sort.Strings(dbNames)
+	return dbNames
+}
+
+// findModel return the model by full name, struct name or table name
+func findModel(name string) (*modelInfo, error) {
+	var found []*modelInfo
+	for _, mi := range getSortedModels("") {
+		if mi.fullName == name || mi.name == name || mi.table == name {
+			found = append(found, mi)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return nil, fmt.Errorf("model %s not registered", name)
+	case 1:
+		return found[0], nil
+	}
+	names := make([]string, len(found))
+	for i, mi := range found {
+		names[i] = mi.fullName
+	}
+	return nil, fmt.Errorf("model %s is ambiguous: %s", name, strings.Join(names, ", "))
+}
@@ -0,0 +1,114 @@
+package orm
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCommandDBs(t *testing.T) {
+	dbs, err := parseCommandDBs(
+		[]string{"root:pass@tcp(127.0.0.1:3306)/app?parseTime=true", "logs=root@/logs"},
+		[]string{"stats=postgres"},
+		[]string{"HOME=/root", "ORM_DSN=env@/app", "ORM_DSN_STATS=host=localhost dbname=stats", "ORM_DSN_LOGS=env@/logs", "ORM_DRIVER=mysql"},
+	)
+	require.NoError(t, err)
+	require.Equal(t, map[string][2]string{
+		"default": {"mysql", "root:pass@tcp(127.0.0.1:3306)/app?parseTime=true"},
+		"logs":    {"mysql", "root@/logs"},
+		"stats":   {"postgres", "host=localhost dbname=stats"},
+	}, dbs)
+	require.Equal(t, []string{"default", "logs", "stats"}, sortedKeys(dbs))
+
+	_, err = parseCommandDBs(nil, []string{"stats=postgres"}, nil)
+	require.Error(t, err, "driver without dsn")
+	_, err = parseCommandDBs([]string{"logs="}, nil, nil)
+	require.Error(t, err, "empty dsn")
+}
+
+func TestRunCommand(t *testing.T) {
+	run := func(args ...string) (int, string, string) {
+		var stdout, stderr bytes.Buffer
+		code := runCommand("orm", args, nil, &stdout, &stderr)
+		return code, stdout.String(), stderr.String()
+	}
+
+	code, stdout, _ := run("sqlall")
+	require.Equal(t, 0, code)
+	require.Contains(t, stdout, "CREATE TABLE IF NOT EXISTS `person` (")
+
+	code, stdout, _ = run("help")
+	require.Equal(t, 0, code)
+	require.Contains(t, stdout, "migrate down [-db name] [-n 1] [-dry-run]")
+
+	for _, args := range [][]string{
+		nil,
+		{"unknown"},
+		{"migrate"},
+		{"migrate", "sideways"},
+		{"sqlall", "extra"},
+		{"syncdb", "-unknown"},
+		{"shards", "create"},
+		{"migrate", "status", "-dry-run"},
+		{"-driver", "logs=mysql", "sqlall"},
+	} {
+		code, _, stderr := run(args...)
+		require.Equal(t, 2, code, "args %v", args)
+		require.NotEmpty(t, stderr, "args %v", args)
+	}
+
+	registered, _ := dbCache.get("default")
+	code, _, stderr := run("-dsn", "other@/other", "sqlall")
+	require.Equal(t, 2, code, "dsn of the registered database")
+	require.Contains(t, stderr, "database default is registered by the application with another dsn or driver")
+	code, _, _ = run("-dsn", registered.DataSource, "-driver", registered.Driver, "sqlall")
+	require.Equal(t, 0, code, "the same dsn")
+
+	code, _, stderr = run("shards", "create", "-model", "noModel")
+	require.Equal(t, 1, code)
+	require.Equal(t, "model noModel not registered\n", stderr)
+
+	code, _, stderr = run("syncdb", "-db", "unknown")
+	require.Equal(t, 1, code, "panic is recovered")
+	require.Contains(t, stderr, "unknown database name unknown")
+
+	mi, err := findModel("person")
+	require.NoError(t, err)
+	require.Equal(t, "shardedPerson", mi.name)
+}
+
+func TestPrintMigrations(t *testing.T) {
+	RegisterMigrationSQL("test_20220201_a", "-- +migrate Up\nCREATE TABLE a (id int);\nCREATE INDEX idx ON a (id);\n"+
+		"-- +migrate Down\nDROP TABLE a;\n")
+	RegisterMigration("test_20220202_b", func(o Ormer) error { return nil }, nil)
+	defer func() {
+		migrationCache.Lock()
+		delete(migrationCache.cache, "test_20220201_a")
+		delete(migrationCache.cache, "test_20220202_b")
+		migrationCache.Unlock()
+	}()
+
+	var stdout bytes.Buffer
+	cmd := &command{stdout: &stdout}
+	statuses := []*MigrationStatus{
+		{Name: "test_20220201_a"},
+		{Name: "test_20220202_b"},
+	}
+	cmd.printMigrations("up", statuses, 0)
+	require.Equal(t, "-- up test_20220201_a\nCREATE TABLE a (id int);\nCREATE INDEX idx ON a (id);\n"+
+		"-- up test_20220202_b\n-- migration written in Go\n", stdout.String())
+
+	stdout.Reset()
+	statuses = []*MigrationStatus{
+		{Name: "test_20220201_a", Applied: true},
+		{Name: "test_20220202_b", Applied: true},
+		{Name: "test_20220110_gone", Applied: true, Missing: true},
+	}
+	cmd.printMigrations("down", statuses, 2)
+	require.Equal(t, "-- down test_20220202_b\n-- migration written in Go\n-- down test_20220201_a\nDROP TABLE a;\n", stdout.String())
+
+	stdout.Reset()
+	cmd.printMigrations("down", statuses, 3)
+	require.Contains(t, stdout.String(), "-- down test_20220110_gone\n-- not registered\n")
+}
@@ -2,6 +2,7 @@ package orm
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"reflect"
 	"strings"
@@ -9,13 +10,26 @@ import (
 	"github.com/std0d9k81/orm/sqlbuilder"
 )
 
+// operators of condition, the last expr of json path is the operator if it's in operators
+var condOperators = map[string]bool{
+	"in": true, "not_in": true, "between": true, "not_between": true,
+	"lt": true, "lte": true, "gt": true, "gte": true, "exact": true, "eq": true, "ne": true,
+	"iexact": true, "contains": true, "icontains": true, "startswith": true, "istartswith": true,
+	"endswith": true, "iendswith": true, "regex": true, "iregex": true, "isnull": true, "isempty": true,
+	"json_contains": true,
+}
+
 type condValue struct {
-	exprs  []string
-	args   []interface{}
-	cond   *Condition
-	isOr   bool
-	isNot  bool
-	isCond bool
+	exprs   []string
+	args    []interface{}
+	cond    *Condition
+	isOr    bool
+	isNot   bool
+	isCond  bool
+	isTuple bool
+	isDesc  bool
+	isRaw   bool
+	raw     string
 }
 
 // Condition struct.
@@ -115,6 +129,40 @@ func (c *Condition) OrNotCond(cond *Condition) *Condition {
 	return c
 }
 
+// AndRaw add raw sql fragment to condition.
+// "?" in sql are the placeholders of args, "{FieldName}" is replaced by the quoted column of field.
+// "??" and "{{" are the literal "?" and "{", and the quoted strings and identifiers in sql are kept as is.
+// for example:
+//	cond := orm.NewCondition().AndRaw("DATEDIFF({Expired}, {Created}) > ?", 30)
+//	//sql-> (DATEDIFF(`expired`, `created`) > ?)
+func (c Condition) AndRaw(sql string, args ...interface{}) *Condition {
+	if sql == "" {
+		panic(fmt.Errorf("<Condition.AndRaw> sql cannot empty"))
+	}
+	c.params = append(c.params, condValue{raw: sql, args: args, isRaw: true})
+	return &c
+}
+
+// OrRaw add OR raw sql fragment to condition.
+// have the same usage as AndRaw
+func (c Condition) OrRaw(sql string, args ...interface{}) *Condition {
+	if sql == "" {
+		panic(fmt.Errorf("<Condition.OrRaw> sql cannot empty"))
+	}
+	c.params = append(c.params, condValue{raw: sql, args: args, isRaw: true, isOr: true})
+	return &c
+}
+
+// andTuple add tuple comparison expression to condition.
+// the fields are compared to values as a whole, e.g. (a, b) > (?, ?), or (a, b) < (?, ?) if desc.
+func (c Condition) andTuple(fields []string, values []interface{}, desc bool) *Condition {
+	if len(fields) == 0 || len(fields) != len(values) {
+		panic(fmt.Errorf("<Condition.andTuple> need same number of fields and values"))
+	}
+	c.params = append(c.params, condValue{exprs: fields, args: values, isTuple: true, isDesc: desc})
+	return &c
+}
+
 // IsEmpty check the condition arguments are empty or not.
 func (c *Condition) IsEmpty() bool {
 	return len(c.params) == 0
@@ -145,13 +193,59 @@ func (c *Condition) GetWhereSQL(mi *modelInfo, cond *sqlbuilder.Cond) string {
 				buf.WriteString(sql)
 				buf.WriteString(")")
 			}
+		} else if p.isRaw {
+			buf.WriteString("(")
+			buf.WriteString(c.getRawSQL(mi, p.raw, p.args, cond))
+			buf.WriteString(")")
+		} else if p.isTuple {
+			columns := make([]string, len(p.exprs))
+			for i, expr := range p.exprs {
+				columns[i] = quote(mi.getFieldInfo(expr).column)
+			}
+			if p.isDesc {
+				buf.WriteString(cond.TupleLessThan(columns, p.args...))
+			} else {
+				buf.WriteString(cond.TupleGreaterThan(columns, p.args...))
+			}
+		} else if len(p.exprs) == 2 && p.exprs[0] == "" {
+			// conditions without field, e.g. "__exists", "__search"
+			buf.WriteString(c.getFieldlessSQL(mi, p.exprs[1], p.args, cond))
 		} else {
 			fi, operator, ok := mi.parseExprs(p.exprs)
 			if !ok {
 				panic(fmt.Errorf("unknown field/column name `%s`", strings.Join(p.exprs, ExprSep)))
 			}
 
-			sql := c.getOperatorSQL(quote(fi.column), operator, p.args, cond)
+			column := quote(fi.column)
+			switch operator {
+			case "year", "month", "day", "date":
+				// transforms of date field, e.g. "Created__year__gte"
+				column = c.getTransformSQL(column, operator, cond)
+				operator = "exact"
+				if len(p.exprs) > 2 {
+					operator = p.exprs[2]
+				}
+			case "json":
+				// path of json field, e.g. "Content__json__user__id__gte"
+				var path []string
+				path, operator = splitJSONPath(p.exprs[2:])
+				column = getJSONExtractSQL(fi, path, cond)
+			case "json_contains":
+				checkJSONField(fi)
+				column = quoteFlavor(cond.Args.Flavor, fi.column)
+			}
+
+			args := make([]interface{}, len(p.args))
+			for i, arg := range p.args {
+				args[i] = resolveExpr(mi, arg, cond.Args.Flavor)
+			}
+
+			var sql string
+			if operator == "search" {
+				sql = c.getSearchSQL(mi, fi, args, cond)
+			} else {
+				sql = c.getOperatorSQL(column, operator, args, cond)
+			}
 			buf.WriteString(sql)
 		}
 	}
@@ -169,14 +263,32 @@ func (c *Condition) getOperatorSQL(column, operator string, args []interface{},
 	switch operator {
 	case "in":
 		if len(args) == 1 {
+			if subquery, ok := getSubquery(args[0]); ok {
+				sql = cond.In(column, subquery)
+				break
+			}
 			args = c.flatArgs(args[0])
 		}
 		sql = cond.In(column, args...)
+	case "not_in":
+		if len(args) == 1 {
+			if subquery, ok := getSubquery(args[0]); ok {
+				sql = cond.NotIn(column, subquery)
+				break
+			}
+			args = c.flatArgs(args[0])
+		}
+		sql = cond.NotIn(column, args...)
 	case "between":
 		if len(args) != 2 {
 			panic(fmt.Errorf("operator `%v` need 2 args not %d", operator, len(args)))
 		}
 		sql = cond.Between(column, args[0], args[1])
+	case "not_between":
+		if len(args) != 2 {
+			panic(fmt.Errorf("operator `%v` need 2 args not %d", operator, len(args)))
+		}
+		sql = cond.NotBetween(column, args[0], args[1])
 	case "lt":
 		if len(args) > 1 {
 			panic(fmt.Errorf("operator `%v` need 1 args not %d", operator, len(args)))
@@ -206,7 +318,7 @@ func (c *Condition) getOperatorSQL(column, operator string, args []interface{},
 		if len(args) > 1 {
 			panic(fmt.Errorf("operator `%v` need 1 args not %d", operator, len(args)))
 		}
-		sql = cond.NE(column, args[0])
+		sql = cond.IsDistinctFrom(column, args[0])
 	case "iexact":
 		if len(args) > 1 {
 			panic(fmt.Errorf("operator `%v` need 1 args not %d", operator, len(args)))
@@ -255,6 +367,38 @@ func (c *Condition) getOperatorSQL(column, operator string, args []interface{},
 		param := strings.Replace(ToStr(args[0]), `%`, `\%`, -1)
 		param = fmt.Sprintf("%%%s", param)
 		sql = cond.Like(column, param)
+	case "regex":
+		if len(args) > 1 {
+			panic(fmt.Errorf("operator `%v` need 1 args not %d", operator, len(args)))
+		}
+		sql = cond.Regexp(column, args[0])
+	case "iregex":
+		if len(args) > 1 {
+			panic(fmt.Errorf("operator `%v` need 1 args not %d", operator, len(args)))
+		}
+		sql = cond.IRegexp(column, args[0])
+	case "isempty":
+		if len(args) > 1 {
+			panic(fmt.Errorf("operator `%v` need 1 args not %d", operator, len(args)))
+		}
+		b, ok := args[0].(bool)
+		if !ok {
+			panic(fmt.Errorf("operator `%v` need a bool value not `%T`", operator, args[0]))
+		}
+		if b {
+			sql = cond.IsEmpty(column)
+		} else {
+			sql = cond.IsNotEmpty(column)
+		}
+	case "json_contains":
+		if len(args) > 1 {
+			panic(fmt.Errorf("operator `%v` need 1 args not %d", operator, len(args)))
+		}
+		data, err := json.Marshal(args[0])
+		if err != nil {
+			panic(fmt.Errorf("operator `%v` need a json value: %v", operator, err))
+		}
+		sql = cond.JSONContains(column, string(data))
 	case "isnull":
 		if len(args) > 1 {
 			panic(fmt.Errorf("operator `%v` need 1 args not %d", operator, len(args)))
@@ -275,6 +419,215 @@ func (c *Condition) getOperatorSQL(column, operator string, args []interface{},
 	return sql
 }
 
+// splitJSONPath split exprs to json path and operator, the operator is "exact" if not specified
+func splitJSONPath(exprs []string) ([]string, string) {
+	if len(exprs) > 1 && condOperators[exprs[len(exprs)-1]] {
+		return exprs[:len(exprs)-1], exprs[len(exprs)-1]
+	}
+	return exprs, "exact"
+}
+
+// getTransformSQL return the expression of transform on column
+func (c *Condition) getTransformSQL(column, transform string, cond *sqlbuilder.Cond) string {
+	switch transform {
+	case "year":
+		return cond.Year(column)
+	case "month":
+		return cond.Month(column)
+	case "day":
+		return cond.Day(column)
+	case "date":
+		return cond.Date(column)
+	default:
+		panic(fmt.Errorf("transform `%v` unknown", transform))
+	}
+}
+
+// getRawSQL return the sql of raw fragment, placeholders are rebound to cond and fields are quoted
+func (c *Condition) getRawSQL(mi *modelInfo, raw string, args []interface{}, cond *sqlbuilder.Cond) string {
+	var (
+		buf = &bytes.Buffer{}
+		n   = 0
+	)
+
+	for i := 0; i < len(raw); i++ {
+		switch raw[i] {
+		case '\'', '"', '`':
+			// copy the quoted literal or identifier as is, the placeholders in it are not replaced
+			end := getQuotedEnd(raw, i)
+			if end < 0 {
+				panic(fmt.Errorf("raw sql `%s` has unclosed quote `%c`", raw, raw[i]))
+			}
+			buf.WriteString(strings.Replace(raw[i:end+1], "$", "$$", -1))
+			i = end
+		case '?':
+			if i+1 < len(raw) && raw[i+1] == '?' {
+				buf.WriteByte('?')
+				i++
+				continue
+			}
+			if n >= len(args) {
+				panic(fmt.Errorf("raw sql `%s` need more than %d args", raw, len(args)))
+			}
+			buf.WriteString(cond.Var(resolveExpr(mi, args[n], cond.Args.Flavor)))
+			n++
+		case '{':
+			if i+1 < len(raw) && raw[i+1] == '{' {
+				buf.WriteByte('{')
+				i++
+				continue
+			}
+			end := strings.IndexByte(raw[i:], '}')
+			if end < 0 {
+				panic(fmt.Errorf("raw sql `%s` has unclosed `{`", raw))
+			}
+			name := raw[i+1 : i+end]
+			fi, ok := mi.fields.GetByAny(name)
+			if !ok {
+				panic(fmt.Errorf("unknown field/column name `%s`", name))
+			}
+			buf.WriteString(sqlbuilder.Escape(quote(fi.column)))
+			i += end
+		case '$':
+			// escape "$" as it's the placeholder of sqlbuilder
+			buf.WriteString("$$")
+		default:
+			buf.WriteByte(raw[i])
+		}
+	}
+
+	if n != len(args) {
+		panic(fmt.Errorf("raw sql `%s` need %d args not %d", raw, n, len(args)))
+	}
+	return buf.String()
+}
+
+// getQuotedEnd return the index of quote closing the one at start of raw, -1 if it's unclosed.
+// the doubled quote and the backslash escaped char in string literal are skipped.
+func getQuotedEnd(raw string, start int) int {
+	quote := raw[start]
+	for i := start + 1; i < len(raw); i++ {
+		switch raw[i] {
+		case '\\':
+			if quote != '`' {
+				i++
+			}
+		case quote:
+			if i+1 < len(raw) && raw[i+1] == quote {
+				i++
+				continue
+			}
+			return i
+		}
+	}
+	return -1
+}
+
+// getFieldlessSQL return the sql of operators without field
+func (c *Condition) getFieldlessSQL(mi *modelInfo, operator string, args []interface{}, cond *sqlbuilder.Cond) string {
+	if len(args) != 1 {
+		panic(fmt.Errorf("operator `%v` need 1 args not %d", operator, len(args)))
+	}
+
+	if operator == "search" {
+		e, ok := args[0].(*SearchExpr)
+		if !ok {
+			panic(fmt.Errorf("operator `%v` need a *SearchExpr not `%T`", operator, args[0]))
+		}
+		return cond.Var(e.predicate(mi, cond.Args.Flavor))
+	}
+
+	subquery, ok := getSubquery(args[0])
+	if !ok {
+		panic(fmt.Errorf("operator `%v` need a QuerySetter or sqlbuilder.Builder not `%T`", operator, args[0]))
+	}
+
+	switch operator {
+	case "exists":
+		return cond.Exists(subquery)
+	case "not_exists":
+		return cond.NotExists(subquery)
+	default:
+		panic(fmt.Errorf("operator `%v` unknown", operator))
+	}
+}
+
+// getSearchSQL return the sql of full-text search on field fi.
+// args are the query and optional SearchMode, e.g. Filter("Title__search", "+golang -java", orm.SearchBoolean)
+func (c *Condition) getSearchSQL(mi *modelInfo, fi *fieldInfo, args []interface{}, cond *sqlbuilder.Cond) string {
+	if len(args) > 2 {
+		panic(fmt.Errorf("operator `search` need at most 2 args not %d", len(args)))
+	}
+	query, ok := args[0].(string)
+	if !ok {
+		panic(fmt.Errorf("operator `search` need a string query not `%T`", args[0]))
+	}
+	var modes []SearchMode
+	if len(args) == 2 {
+		mode, ok := args[1].(SearchMode)
+		if !ok {
+			panic(fmt.Errorf("operator `search` need a SearchMode not `%T`", args[1]))
+		}
+		modes = append(modes, mode)
+	}
+	return cond.Var(Match(fi.name).Against(query, modes...).predicate(mi, cond.Args.Flavor))
+}
+
+// getSubquery return the builder if arg is a QuerySetter or sqlbuilder.Builder.
+// the builder is compiled into the args of outer query, so the placeholders are numbered correctly.
+// the error of QuerySetter is returned by checkSubqueries before the outer query is built.
+func getSubquery(arg interface{}) (sqlbuilder.Builder, bool) {
+	switch v := arg.(type) {
+	case *querySetter:
+		builder, err := v.getSubquery()
+		if err != nil {
+			panic(err)
+		}
+		return builder, true
+	case sqlbuilder.Builder:
+		return v, true
+	}
+	return nil, false
+}
+
+// isSubquery check arg is a QuerySetter or sqlbuilder.Builder used as a subquery
+func isSubquery(arg interface{}) bool {
+	switch arg.(type) {
+	case *querySetter, sqlbuilder.Builder:
+		return true
+	}
+	return false
+}
+
+// checkSubqueries return the error of the table queried by the QuerySetter used as a subquery,
+// e.g. ErrNoTableSuffix if the QuerySetter of sharded model has no table suffix.
+func (c *Condition) checkSubqueries() error {
+	if c == nil {
+		return nil
+	}
+	for _, p := range c.params {
+		if p.isCond {
+			if err := p.cond.checkSubqueries(); err != nil {
+				return err
+			}
+			continue
+		}
+		for _, arg := range p.args {
+			qs, ok := arg.(*querySetter)
+			if !ok {
+				continue
+			}
+			if _, err := qs.getTable(); err != nil {
+				return err
+			}
+			if err := qs.cond.checkSubqueries(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 func (c Condition) flatArgs(arg interface{}) []interface{} {
 	val := reflect.ValueOf(arg)
 	kind := val.Kind()
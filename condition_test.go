@@ -2,6 +2,7 @@ package orm
 
 import (
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/std0d9k81/orm/sqlbuilder"
@@ -45,7 +46,7 @@ func TestCondition(t *testing.T) {
 	assert.Equal(t, "`id` = $0", sql, "eq failed")
 
 	sql = NewCondition().And("ID__ne", 10).GetWhereSQL(mi, newSqlBuilderCond())
-	assert.Equal(t, "`id` <> $0", sql, "ne failed")
+	assert.Equal(t, "NOT (`id` <=> $0)", sql, "ne failed")
 
 	sql = NewCondition().And("ID__in", 10, 20, 30).GetWhereSQL(mi, newSqlBuilderCond())
 	assert.Equal(t, "`id` IN ($0, $1, $2)", sql, "in failed")
@@ -83,3 +84,216 @@ func TestCondition(t *testing.T) {
 	sql = NewCondition().And("ID", 1).OrCond(NewCondition().And("ID", 10).Or("name", "zhang")).GetWhereSQL(mi, newSqlBuilderCond())
 	assert.Equal(t, "`id` = $0 OR (`id` = $1 OR `name` = $2)", sql, "And().OrCond(And().Or()) failed")
 }
+
+func TestConditionSubquery(t *testing.T) {
+	person := &Person{}
+	mi := newModelInfo(reflect.ValueOf(person))
+	mi.table = "person"
+	qs := &querySetter{mi: mi, cond: NewCondition().And("Name", "zhang")}
+
+	builder := sqlbuilder.PostgreSQL.NewSelectBuilder()
+	cond := NewCondition().And("ID__gt", 1).And("ID__in", qs).And("Name", "li")
+	builder.Select("*").From("person").Where(cond.GetWhereSQL(mi, &builder.Cond))
+	sql, args := builder.Build()
+	assert.Equal(t, "SELECT * FROM person WHERE `id` > $1 AND `id` IN (SELECT `id` FROM `person` WHERE `name` = $2) AND `name` = $3", sql, "in subquery failed")
+	assert.Equal(t, []interface{}{1, "zhang", "li"}, args)
+
+	builder = sqlbuilder.NewSelectBuilder()
+	cond = NewCondition().And("ID__in", qs.Select("Name").Distinct())
+	builder.Select("*").From("person").Where(cond.GetWhereSQL(mi, &builder.Cond))
+	sql, _ = builder.Build()
+	assert.Equal(t, "SELECT * FROM person WHERE `id` IN (SELECT DISTINCT `name` FROM `person` WHERE `name` = ?)", sql, "in subquery with select failed")
+
+	builder = sqlbuilder.PostgreSQL.NewSelectBuilder()
+	sub := sqlbuilder.NewSelectBuilder()
+	sub.Select("1").From("post").Where(sub.E("post.person_id", 10))
+	cond = NewCondition().And("Name", "li").And("__exists", sub).AndNot("__exists", qs).And("__not_exists", qs)
+	builder.Select("*").From("person").Where(cond.GetWhereSQL(mi, &builder.Cond))
+	sql, args = builder.Build()
+	assert.Equal(t, "SELECT * FROM person WHERE `name` = $1 AND EXISTS (SELECT 1 FROM post WHERE post.person_id = $2) AND "+
+		"NOT EXISTS (SELECT `id` FROM `person` WHERE `name` = $3) AND NOT EXISTS (SELECT `id` FROM `person` WHERE `name` = $4)", sql, "exists failed")
+	assert.Equal(t, []interface{}{"li", 10, "zhang", "zhang"}, args)
+
+	assert.Panics(t, func() {
+		NewCondition().And("__exists", 1).GetWhereSQL(mi, newSqlBuilderCond())
+	}, "exists without subquery")
+}
+
+func TestConditionFExpr(t *testing.T) {
+	person := &Person{}
+	mi := newModelInfo(reflect.ValueOf(person))
+
+	sql := NewCondition().And("ID__lt", F("Name")).GetWhereSQL(mi, newSqlBuilderCond())
+	assert.Equal(t, "`id` < $0", sql, "lt field failed")
+
+	builder := sqlbuilder.PostgreSQL.NewSelectBuilder()
+	cond := NewCondition().And("Name", "zhang").And("ID__gte", F("ID").Mul(F("Name")).Add(10)).And("ID__in", F("Name"), 5)
+	builder.Select("*").From("person").Where(cond.GetWhereSQL(mi, &builder.Cond))
+	sql, args := builder.Build()
+	assert.Equal(t, "SELECT * FROM person WHERE `name` = $1 AND `id` >= ((`id` * `name`) + $2) AND `id` IN (`name`, $3)", sql, "field expression failed")
+	assert.Equal(t, []interface{}{"zhang", 10, 5}, args)
+
+	assert.Panics(t, func() {
+		NewCondition().And("ID", F("Age")).GetWhereSQL(mi, newSqlBuilderCond())
+	}, "unknown field")
+}
+
+func TestConditionRaw(t *testing.T) {
+	person := &Person{}
+	mi := newModelInfo(reflect.ValueOf(person))
+
+	sql := NewCondition().AndRaw("LENGTH({Name}) > ?", 3).GetWhereSQL(mi, newSqlBuilderCond())
+	assert.Equal(t, "(LENGTH(`name`) > $0)", sql, "and raw failed")
+
+	builder := sqlbuilder.PostgreSQL.NewSelectBuilder()
+	cond := NewCondition().And("ID", 1).OrRaw("{id} BETWEEN ? AND ? AND {Name} <> '$'", 10, 20).AndRaw("{ID} % 2 = 0")
+	cond = NewCondition().AndCond(cond).And("Name", "zhang")
+	builder.Select("*").From("person").Where(cond.GetWhereSQL(mi, &builder.Cond))
+	sql, args := builder.Build()
+	assert.Equal(t, "SELECT * FROM person WHERE (`id` = $1 OR (`id` BETWEEN $2 AND $3 AND `name` <> '$') AND (`id` % 2 = 0)) AND `name` = $4", sql, "raw in tree failed")
+	assert.Equal(t, []interface{}{1, 10, 20, "zhang"}, args)
+
+	assert.Panics(t, func() {
+		NewCondition().AndRaw("{ID} = ? OR {ID} = ?", 1).GetWhereSQL(mi, newSqlBuilderCond())
+	}, "less args")
+	assert.Panics(t, func() {
+		NewCondition().AndRaw("{ID} = ?", 1, 2).GetWhereSQL(mi, newSqlBuilderCond())
+	}, "more args")
+	assert.Panics(t, func() {
+		NewCondition().AndRaw("{Age} = ?", 1).GetWhereSQL(mi, newSqlBuilderCond())
+	}, "unknown field")
+	assert.Panics(t, func() {
+		NewCondition().AndRaw("{Name} = 'it''s", 1).GetWhereSQL(mi, newSqlBuilderCond())
+	}, "unclosed quote")
+
+	cases := map[string]*Condition{
+		"(`name` = '{\"a\":1}')":                    NewCondition().AndRaw("{Name} = '{\"a\":1}'"),
+		"(`name` IN ('?', \"{?}\") AND `id` = $0)":  NewCondition().AndRaw("{Name} IN ('?', \"{?}\") AND {ID} = ?", 1),
+		"(`name` = 'it''s ? \\' {x}' OR `id` = $0)": NewCondition().AndRaw("{Name} = 'it''s ? \\' {x}' OR {ID} = ?", 1),
+		"(`name` LIKE '$$%' AND `a?b{` = 1)":        NewCondition().AndRaw("{Name} LIKE '$%' AND `a?b{` = 1"),
+		"(JSON_CONTAINS_PATH(`name`, 'one', '$$.a') ?| ARRAY[$0] {id})": NewCondition().AndRaw(
+			"JSON_CONTAINS_PATH({Name}, 'one', '$.a') ??| ARRAY[?] {{id}", "a"),
+	}
+	for expected, cond := range cases {
+		assert.Equal(t, expected, cond.GetWhereSQL(mi, newSqlBuilderCond()))
+	}
+}
+
+func TestConditionExtendedOperators(t *testing.T) {
+	person := &Person{}
+	mi := newModelInfo(reflect.ValueOf(person))
+
+	cases := map[string]*Condition{
+		"`id` NOT IN ($0, $1)":                   NewCondition().And("ID__not_in", 10, 20),
+		"`id` NOT IN ($0, $1, $2)":               NewCondition().And("ID__not_in", []int{10, 20, 30}),
+		"1 = 1":                                  NewCondition().And("ID__not_in", []int{}),
+		"0 = 1":                                  NewCondition().And("ID__in", []int64{}),
+		"`id` NOT BETWEEN $0 AND $1":             NewCondition().And("ID__not_between", 10, 20),
+		"`name` REGEXP BINARY $0":                NewCondition().And("Name__regex", "^z"),
+		"`name` REGEXP $0":                       NewCondition().And("Name__iregex", "^z"),
+		"(`name` IS NULL OR `name` = '')":        NewCondition().And("Name__isempty", true),
+		"(`name` IS NOT NULL AND `name` <> '')":  NewCondition().And("Name__isempty", false),
+		"YEAR(`name`) = $0":                      NewCondition().And("Name__year", 2020),
+		"MONTH(`name`) >= $0":                    NewCondition().And("Name__month__gte", 6),
+		"DAY(`name`) IN ($0, $1)":                NewCondition().And("Name__day__in", 1, 15),
+		"NOT DATE(`name`) BETWEEN $0 AND $1":     NewCondition().AndNot("Name__date__between", "2020-01-01", "2020-12-31"),
+		"NOT (`id` <=> $0) AND `id` NOT IN ($1)": NewCondition().And("ID__ne", 1).And("ID__not_in", 2),
+	}
+	for expected, cond := range cases {
+		assert.Equal(t, expected, cond.GetWhereSQL(mi, newSqlBuilderCond()))
+	}
+
+	builder := sqlbuilder.PostgreSQL.NewSelectBuilder()
+	cond := NewCondition().And("ID__ne", 1).And("Name__iregex", "^z").And("Name__year__lt", 2020).And("Name__date", "2020-01-01")
+	builder.Select("*").From("person").Where(cond.GetWhereSQL(mi, &builder.Cond))
+	sql, _ := builder.Build()
+	assert.Equal(t, "SELECT * FROM person WHERE `id` IS DISTINCT FROM $1 AND `name` ~* $2 AND "+
+		"EXTRACT(YEAR FROM `name`) < $3 AND CAST(`name` AS DATE) = $4", sql, "postgresql operators failed")
+}
+
+func TestConditionJSON(t *testing.T) {
+	mi := newModelInfo(reflect.ValueOf(&jsonModel{}))
+
+	cases := map[string]*Condition{
+		"JSON_EXTRACT(`content`, '$$.type') = $0":                     NewCondition().And("Content__json__type", "a"),
+		"JSON_EXTRACT(`content`, '$$.data.items[0]') >= $0":           NewCondition().And("Content__json__data__items__0__gte", 10),
+		"JSON_EXTRACT(`content_ptr`, '$$.type') IN ($0, $1)":          NewCondition().And("ContentPtr__json__type__in", "a", "b"),
+		"JSON_CONTAINS(JSON_EXTRACT(`content`, '$$.data.items'), $0)": NewCondition().And("Content__json__data__items__json_contains", 3),
+		"JSON_CONTAINS(`content`, $0)":                                NewCondition().And("Content__json_contains", map[string]string{"type": "a"}),
+		"NOT JSON_EXTRACT(`content`, '$$.data.value') IS NULL":        NewCondition().AndNot("Content__json__data__value__isnull", true),
+	}
+	for expected, cond := range cases {
+		assert.Equal(t, expected, cond.GetWhereSQL(mi, newSqlBuilderCond()))
+	}
+
+	builder := sqlbuilder.NewSelectBuilder()
+	cond := NewCondition().And("Content__json__type", "a").And("Content__json_contains", []string{"vip"})
+	builder.Select("*").From("json_model").Where(cond.GetWhereSQL(mi, &builder.Cond)).
+		OrderBy(mi.getOrderByCols([]string{"-Content__json__data__value", "ID"}, nil, &builder.Cond)...)
+	sql, args := builder.Build()
+	assert.Equal(t, "SELECT * FROM json_model WHERE JSON_EXTRACT(`content`, '$.type') = ? AND JSON_CONTAINS(`content`, ?) "+
+		"ORDER BY JSON_EXTRACT(`content`, '$.data.value') DESC, `id` ASC", sql, "mysql json failed")
+	assert.Equal(t, []interface{}{"a", `["vip"]`}, args)
+
+	builder = sqlbuilder.PostgreSQL.NewSelectBuilder()
+	builder.Select("*").From("json_model").Where(cond.GetWhereSQL(mi, &builder.Cond)).
+		OrderBy(mi.getOrderByCols([]string{"Content__json__data__items__1"}, nil, &builder.Cond)...)
+	sql, _ = builder.Build()
+	assert.Equal(t, `SELECT * FROM json_model WHERE (CAST("content" AS JSONB) ->> 'type') = $1 AND CAST("content" AS JSONB) @> CAST($2 AS JSONB) `+
+		`ORDER BY (CAST("content" AS JSONB) -> 'data' -> 'items' ->> 1) ASC`, sql, "postgresql json failed")
+
+	assert.Panics(t, func() {
+		NewCondition().And("ID__json__a", 1).GetWhereSQL(mi, newSqlBuilderCond())
+	}, "not json field")
+	assert.Panics(t, func() {
+		NewCondition().And("ID__json_contains", 1).GetWhereSQL(mi, newSqlBuilderCond())
+	}, "not json field")
+	assert.Panics(t, func() {
+		NewCondition().And("Content__json__a'b", 1).GetWhereSQL(mi, newSqlBuilderCond())
+	}, "invalid json path")
+	assert.Panics(t, func() {
+		NewCondition().And("Content__json", 1).GetWhereSQL(mi, newSqlBuilderCond())
+	}, "empty json path")
+}
+
+func TestConditionSearch(t *testing.T) {
+	person := &Person{}
+	mi := newModelInfo(reflect.ValueOf(person))
+
+	cases := map[string]*Condition{
+		"MATCH(`name`) AGAINST($0 IN NATURAL LANGUAGE MODE)":           NewCondition().And("Name__search", "zhang"),
+		"MATCH(`name`) AGAINST($0 IN BOOLEAN MODE)":                    NewCondition().And("Name__search", "+zhang -li", SearchBoolean),
+		"MATCH(`id`, `name`) AGAINST($0 WITH QUERY EXPANSION)":         NewCondition().And("__search", Match("ID", "Name").Against("zhang", SearchExpansion)),
+		"NOT MATCH(`id`, `name`) AGAINST($0 IN NATURAL LANGUAGE MODE)": NewCondition().AndNot("__search", Match("ID", "name").Against("zhang")),
+	}
+	for expected, cond := range cases {
+		builder := sqlbuilder.NewSelectBuilder()
+		sql := cond.GetWhereSQL(mi, &builder.Cond)
+		builder.Select("*").From("person").Where(sql)
+		query, args := builder.Build()
+		assert.Equal(t, "SELECT * FROM person WHERE "+strings.Replace(expected, "$0", "?", 1), query)
+		assert.Len(t, args, 1)
+	}
+
+	cond := NewCondition().And("ID", 1).And("__search", Match("ID", "Name").Against("zhang li")).And("Name__search", "wang", SearchBoolean)
+
+	builder := sqlbuilder.PostgreSQL.NewSelectBuilder()
+	builder.Select("*").From("person").Where(cond.GetWhereSQL(mi, &builder.Cond))
+	sql, args := builder.Build()
+	assert.Equal(t, "SELECT * FROM person WHERE `id` = $1 AND "+`to_tsvector(concat_ws(' ', "id", "name")) @@ plainto_tsquery($2) AND `+
+		`to_tsvector("name") @@ to_tsquery($3)`, sql, "postgresql search failed")
+	assert.Equal(t, []interface{}{1, "zhang li", "wang"}, args)
+
+	builder = sqlbuilder.SQLite.NewSelectBuilder()
+	builder.Select("*").From("person").Where(cond.GetWhereSQL(mi, &builder.Cond))
+	sql, args = builder.Build()
+	assert.Equal(t, "SELECT * FROM person WHERE `id` = ? AND "+`("id" MATCH ? OR "name" MATCH ?) AND "name" MATCH ?`, sql, "sqlite search failed")
+	assert.Equal(t, []interface{}{1, "zhang li", "zhang li", "wang"}, args)
+
+	assert.Panics(t, func() {
+		NewCondition().And("__search", "zhang").GetWhereSQL(mi, newSqlBuilderCond())
+	}, "search without expression")
+	assert.Panics(t, func() {
+		NewCondition().And("Name__search", 1).GetWhereSQL(mi, newSqlBuilderCond())
+	}, "search not string")
+}
@@ -4,10 +4,13 @@ import (
 	"database/sql"
 	"fmt"
 	"time"
+
+	"github.com/std0d9k81/orm/sqlbuilder"
 )
 
 type database struct {
 	Name            string
+	Driver          string
 	DataSource      string
 	MaxIdleConns    int
 	MaxOpenConns    int
@@ -23,10 +26,22 @@ func getDB(name string) *database {
 	panic(fmt.Errorf("unknown database name %v", name))
 }
 
+// flavor return the sql flavor of database driver, MySQL by default
+func (db *database) flavor() sqlbuilder.Flavor {
+	switch db.Driver {
+	case "postgres", "pgx":
+		return sqlbuilder.PostgreSQL
+	case "sqlite3", "sqlite":
+		return sqlbuilder.SQLite
+	}
+	return sqlbuilder.MySQL
+}
+
 // RegisterDB Setting the database connect params. Use the database driver self dataSource args.
 func RegisterDB(dbName, driverName, dataSource string, params ...interface{}) {
 	db := new(database)
 	db.Name = dbName
+	db.Driver = driverName
 	db.DataSource = dataSource
 
 	var err error
@@ -0,0 +1,380 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/std0d9k81/orm/sqlbuilder"
+)
+
+var (
+	nullStringType  = reflect.TypeOf(sql.NullString{})
+	nullInt64Type   = reflect.TypeOf(sql.NullInt64{})
+	nullFloat64Type = reflect.TypeOf(sql.NullFloat64{})
+	nullBoolType    = reflect.TypeOf(sql.NullBool{})
+)
+
+// DefaultStringSize the size of varchar column if the size tag is not specified
+var DefaultStringSize = 255
+
+// SQLAll return the DDL to create the tables of all registered models, in the flavor of their databases.
+// the tables of sharded models are the base tables, the shards can be created by ProvisionShards.
+func SQLAll() string {
+	buf := &strings.Builder{}
+	for _, mi := range getSortedModels("") {
+		fmt.Fprintf(buf, "-- %s\n", mi.fullName)
+		for _, query := range mi.getCreateTableSQL(mi.getFlavor()) {
+			buf.WriteString(query)
+			buf.WriteString(";\n")
+		}
+		buf.WriteString("\n")
+	}
+	return buf.String()
+}
+
+// SyncDB create the tables of models registered to database dbName if not exist.
+// force drop and recreate the existing tables, verbose print the DDL executed.
+func SyncDB(dbName string, force, verbose bool) error {
+	var out io.Writer
+	if verbose {
+		out = os.Stdout
+	}
+	return syncDB(dbName, force, false, out)
+}
+
+// syncDB create the tables of models, the DDL is printed to out if not nil, and not executed if dryRun.
+func syncDB(dbName string, force, dryRun bool, out io.Writer) error {
+	BootStrap()
+
+	var (
+		db     = getDB(dbName)
+		flavor = db.flavor()
+		ctx    = context.Background()
+	)
+
+	exec := func(query string) error {
+		if out != nil {
+			fmt.Fprintf(out, "%s;\n", query)
+		}
+		if dryRun {
+			return nil
+		}
+		_, err := db.DB.ExecContext(ctx, query)
+		return err
+	}
+
+	for _, mi := range getSortedModels(dbName) {
+		exist, err := tableExists(ctx, db.DB, flavor, mi.table)
+		if err != nil {
+			return err
+		}
+
+		if exist && force {
+			if err = exec("DROP TABLE " + flavor.Quote(mi.table)); err != nil {
+				return err
+			}
+			exist = false
+		}
+
+		if exist {
+			if out != nil {
+				fmt.Fprintf(out, "-- table %s already exists, skip\n", flavor.Quote(mi.table))
+			}
+			continue
+		}
+
+		for _, query := range mi.getCreateTableSQL(flavor) {
+			if err = exec(query); err != nil {
+				return fmt.Errorf("create table %s: %v", mi.table, err)
+			}
+		}
+	}
+	return nil
+}
+
+// getSortedModels return the models registered to database dbName in order of table name, all models if dbName is empty
+func getSortedModels(dbName string) []*modelInfo {
+	models := make([]*modelInfo, 0, len(modelCache.cache))
+	for _, mi := range modelCache.cache {
+		if dbName == "" || mi.db == dbName {
+			models = append(models, mi)
+		}
+	}
+	sort.Slice(models, func(i, j int) bool {
+		if models[i].table != models[j].table {
+			return models[i].table < models[j].table
+		}
+		return models[i].fullName < models[j].fullName
+	})
+	return models
+}
+
+// tableExists check the table exists in the current schema of database or not
+func tableExists(ctx context.Context, db dbQueryer, flavor sqlbuilder.Flavor, table string) (bool, error) {
+	var query string
+	switch flavor {
+	case sqlbuilder.PostgreSQL:
+		query = "SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = current_schema() AND table_name = $1"
+	case sqlbuilder.SQLite:
+		query = "SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?"
+	default:
+		query = "SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name = ?"
+	}
+
+	var count int
+	if err := db.QueryRowContext(ctx, query, table).Scan(&count); err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// getFlavor return the sql flavor of the database of model, MySQL if the database is not registered
+func (mi *modelInfo) getFlavor() sqlbuilder.Flavor {
+	if db, ok := dbCache.get(mi.db); ok {
+		return db.flavor()
+	}
+	return sqlbuilder.MySQL
+}
+
+// getCreateTableSQL return the CREATE TABLE statement of model, followed by the CREATE INDEX statements
+func (mi *modelInfo) getCreateTableSQL(flavor sqlbuilder.Flavor) []string {
+	return mi.getCreateTableSQLOf(flavor, mi.table)
+}
+
+// getCreateTableSQLOf return the DDL of model like getCreateTableSQL with the table name, e.g. the shard table
+func (mi *modelInfo) getCreateTableSQLOf(flavor sqlbuilder.Flavor, tableName string) []string {
+	var (
+		table   = flavor.Quote(tableName)
+		columns = make([]string, 0, len(mi.fields.fieldsDB)+1)
+		indexes []string
+	)
+
+	for _, fi := range mi.fields.fieldsDB {
+		columns = append(columns, "    "+fi.getColumnSQL(flavor))
+	}
+
+	for _, index := range mi.indexes {
+		keyword := "INDEX"
+		if index.unique {
+			keyword = "UNIQUE INDEX"
+		}
+		quoted := make([]string, len(index.fields))
+		for i, column := range index.columns() {
+			quoted[i] = flavor.Quote(column)
+		}
+		indexes = append(indexes, fmt.Sprintf("CREATE %s %s ON %s (%s)",
+			keyword, flavor.Quote(index.getName(tableName)), table, strings.Join(quoted, ", ")))
+	}
+
+	// the auto pk of SQLite is declared in column
+	if pk := mi.fields.pk; pk != nil && !(flavor == sqlbuilder.SQLite && pk.auto) {
+		columns = append(columns, fmt.Sprintf("    PRIMARY KEY (%s)", flavor.Quote(pk.column)))
+	}
+
+	query := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (\n%s\n)", table, strings.Join(columns, ",\n"))
+	if flavor == sqlbuilder.MySQL {
+		query += " ENGINE=InnoDB DEFAULT CHARSET=utf8mb4"
+	}
+	return append([]string{query}, indexes...)
+}
+
+// getColumnSQL return the column definition of field
+func (fi *fieldInfo) getColumnSQL(flavor sqlbuilder.Flavor) string {
+	columnType := fi.getColumnType(flavor)
+
+	if fi.auto {
+		switch flavor {
+		case sqlbuilder.PostgreSQL:
+			switch columnType {
+			case "smallint":
+				columnType = "smallserial"
+			case "integer":
+				columnType = "serial"
+			default:
+				columnType = "bigserial"
+			}
+		case sqlbuilder.SQLite:
+			return fmt.Sprintf("%s integer PRIMARY KEY AUTOINCREMENT", flavor.Quote(fi.column))
+		default:
+			columnType += " NOT NULL AUTO_INCREMENT"
+		}
+		return fmt.Sprintf("%s %s", flavor.Quote(fi.column), columnType)
+	}
+
+	definition := fmt.Sprintf("%s %s", flavor.Quote(fi.column), columnType)
+	if fi.isNullable() {
+		definition += " NULL"
+	} else {
+		definition += " NOT NULL"
+	}
+	if fi.hasInitial {
+		definition += " DEFAULT " + getDefaultSQL(fi.initial)
+	}
+	return definition
+}
+
+// isNullable return true if the column of field can be NULL, which is the pointer, sql.Null* or has the null tag.
+// the json field is never written as NULL, so it's nullable only with the null tag.
+func (fi *fieldInfo) isNullable() bool {
+	if fi.pk {
+		return false
+	}
+	if fi.json {
+		return fi.null
+	}
+	if fi.null || fi.sf.Type.Kind() == reflect.Ptr {
+		return true
+	}
+	switch fi.sf.Type {
+	case nullStringType, nullInt64Type, nullFloat64Type, nullBoolType:
+		return true
+	}
+	return false
+}
+
+// getColumnType return the column type of field, the type tag is prior, then the digits and decimals tags
+// nolint:gocyclo
+func (fi *fieldInfo) getColumnType(flavor sqlbuilder.Flavor) string {
+	if fi.dbType != "" {
+		return fi.dbType
+	}
+
+	if fi.json {
+		if flavor == sqlbuilder.MySQL {
+			return "longtext"
+		}
+		return "text"
+	}
+
+	if fi.digits > 0 {
+		if flavor == sqlbuilder.PostgreSQL {
+			return fmt.Sprintf("numeric(%d,%d)", fi.digits, fi.decimals)
+		}
+		return fmt.Sprintf("decimal(%d,%d)", fi.digits, fi.decimals)
+	}
+
+	typ := fi.sf.Type
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	kind := typ.Kind()
+	switch typ {
+	case timeType:
+		if flavor == sqlbuilder.PostgreSQL {
+			return "timestamp with time zone"
+		}
+		return "datetime"
+	case nullStringType:
+		kind = reflect.String
+	case nullInt64Type:
+		kind = reflect.Int64
+	case nullFloat64Type:
+		kind = reflect.Float64
+	case nullBoolType:
+		kind = reflect.Bool
+	}
+
+	if flavor == sqlbuilder.SQLite && isIntegerKind(kind) {
+		return "integer"
+	}
+
+	switch kind {
+	case reflect.Bool:
+		switch flavor {
+		case sqlbuilder.PostgreSQL:
+			return "boolean"
+		case sqlbuilder.SQLite:
+			return "integer"
+		}
+		return "tinyint(1)"
+	case reflect.Int8:
+		if flavor == sqlbuilder.PostgreSQL {
+			return "smallint"
+		}
+		return "tinyint"
+	case reflect.Int16:
+		return "smallint"
+	case reflect.Int32:
+		if flavor == sqlbuilder.PostgreSQL {
+			return "integer"
+		}
+		return "int"
+	case reflect.Int, reflect.Int64:
+		return "bigint"
+	case reflect.Uint8:
+		if flavor == sqlbuilder.PostgreSQL {
+			return "smallint"
+		}
+		return "tinyint unsigned"
+	case reflect.Uint16:
+		if flavor == sqlbuilder.PostgreSQL {
+			return "integer"
+		}
+		return "smallint unsigned"
+	case reflect.Uint32:
+		if flavor == sqlbuilder.PostgreSQL {
+			return "bigint"
+		}
+		return "int unsigned"
+	case reflect.Uint, reflect.Uint64:
+		if flavor == sqlbuilder.PostgreSQL {
+			return "bigint"
+		}
+		return "bigint unsigned"
+	case reflect.Float32:
+		if flavor == sqlbuilder.MySQL {
+			return "float"
+		}
+		return "real"
+	case reflect.Float64:
+		switch flavor {
+		case sqlbuilder.PostgreSQL:
+			return "double precision"
+		case sqlbuilder.SQLite:
+			return "real"
+		}
+		return "double"
+	case reflect.String:
+		size := fi.size
+		if size <= 0 {
+			size = DefaultStringSize
+		}
+		return "varchar(" + strconv.Itoa(size) + ")"
+	case reflect.Slice:
+		if typ.Elem().Kind() == reflect.Uint8 {
+			switch flavor {
+			case sqlbuilder.PostgreSQL:
+				return "bytea"
+			case sqlbuilder.SQLite:
+				return "blob"
+			}
+			return "longblob"
+		}
+	}
+
+	panic(fmt.Errorf("field `%s`: unsupported type `%s`, use the type tag to specify the column type", fi.fullName, fi.sf.Type))
+}
+
+// getDefaultSQL return the DEFAULT value of column, the numbers, quoted strings and keywords are used as is,
+// others are quoted as string, e.g. default(0), default(CURRENT_TIMESTAMP), default('none') and default(none)
+func getDefaultSQL(value string) string {
+	if _, err := strconv.ParseFloat(value, 64); err == nil {
+		return value
+	}
+	if len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'' {
+		return value
+	}
+	switch strings.ToUpper(value) {
+	case "NULL", "TRUE", "FALSE", "CURRENT_TIMESTAMP", "CURRENT_DATE", "CURRENT_TIME":
+		return value
+	}
+	return "'" + strings.Replace(value, "'", "''", -1) + "'"
+}
@@ -0,0 +1,162 @@
+package orm
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/std0d9k81/orm/sqlbuilder"
+	"github.com/stretchr/testify/require"
+)
+
+type ddlModel struct {
+	ID       uint64          `orm:"column(id);pk;auto"`
+	Name     string          `orm:"size(64);unique"`
+	Nick     *string         `orm:"size(32)"`
+	Email    sql.NullString  `orm:"index"`
+	Age      int8            `orm:"default(0)"`
+	Score    float64         `orm:"null"`
+	Enabled  bool            `orm:"default(true)"`
+	Bio      string          `orm:"type(text)"`
+	Status   string          `orm:"size(8);default(it's)"`
+	Avatar   []byte          `orm:"column(avatar)"`
+	Profile  map[string]int  `orm:"json"`
+	Created  time.Time       `orm:"index;default(CURRENT_TIMESTAMP)"`
+	Balance  sql.NullFloat64 `orm:"column(balance)"`
+	Children int32           `orm:"column(children)"`
+}
+
+type decimalModel struct {
+	ID      int64     `orm:"column(id);pk;auto"`
+	Price   float64   `orm:"digits(10);decimals(2)"`
+	Amount  *string   `orm:"digits(6);decimals(3)"`
+	Status  int8      `orm:"default(1)"`
+	Note    *string   `orm:"size(4);default(none)"`
+	Updated time.Time `orm:"readonly;default(CURRENT_TIMESTAMP)"`
+}
+
+type indexModel struct {
+	ID      int64  `orm:"column(id);pk;auto"`
+	UserID  int64  `orm:"column(user_id)"`
+	Status  int8   `orm:"index"`
+	Code    string `orm:"size(16)"`
+	Created int64
+}
+
+func (m *indexModel) TableIndex() [][]string {
+	return [][]string{{"UserID", "created"}}
+}
+
+func (m *indexModel) TableUnique() [][]string {
+	return [][]string{{"Code", "UserID"}}
+}
+
+type wrongIndexModel struct {
+	ID int64 `orm:"column(id);pk"`
+}
+
+func (m *wrongIndexModel) TableIndex() [][]string {
+	return [][]string{{"ID", "Name"}}
+}
+
+func TestCreateTableSQL(t *testing.T) {
+	mi := newModelInfo(reflect.ValueOf(&ddlModel{}))
+	mi.table = "ddl_model"
+
+	require.Equal(t, []string{"CREATE TABLE IF NOT EXISTS `ddl_model` (\n" +
+		"    `id` bigint unsigned NOT NULL AUTO_INCREMENT,\n" +
+		"    `name` varchar(64) NOT NULL,\n" +
+		"    `nick` varchar(32) NULL,\n" +
+		"    `email` varchar(255) NULL,\n" +
+		"    `age` tinyint NOT NULL DEFAULT 0,\n" +
+		"    `score` double NULL,\n" +
+		"    `enabled` tinyint(1) NOT NULL DEFAULT true,\n" +
+		"    `bio` text NOT NULL,\n" +
+		"    `status` varchar(8) NOT NULL DEFAULT 'it''s',\n" +
+		"    `avatar` longblob NOT NULL,\n" +
+		"    `profile` longtext NOT NULL,\n" +
+		"    `created` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,\n" +
+		"    `balance` double NULL,\n" +
+		"    `children` int NOT NULL,\n" +
+		"    PRIMARY KEY (`id`)\n" +
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+		"CREATE UNIQUE INDEX `uniq_ddl_model_name` ON `ddl_model` (`name`)",
+		"CREATE INDEX `idx_ddl_model_email` ON `ddl_model` (`email`)",
+		"CREATE INDEX `idx_ddl_model_created` ON `ddl_model` (`created`)",
+	}, mi.getCreateTableSQL(sqlbuilder.MySQL))
+
+	queries := mi.getCreateTableSQL(sqlbuilder.PostgreSQL)
+	require.Len(t, queries, 4)
+	require.Contains(t, queries[0], `"id" bigserial,`)
+	require.Contains(t, queries[0], `"age" smallint NOT NULL DEFAULT 0,`)
+	require.Contains(t, queries[0], `"enabled" boolean NOT NULL DEFAULT true,`)
+	require.Contains(t, queries[0], `"avatar" bytea NOT NULL,`)
+	require.Contains(t, queries[0], `"created" timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,`)
+	require.Contains(t, queries[0], `"score" double precision NULL,`)
+	require.Contains(t, queries[0], `PRIMARY KEY ("id")`)
+	require.Equal(t, `CREATE INDEX "idx_ddl_model_email" ON "ddl_model" ("email")`, queries[2])
+
+	queries = mi.getCreateTableSQL(sqlbuilder.SQLite)
+	require.Contains(t, queries[0], `"id" integer PRIMARY KEY AUTOINCREMENT,`)
+	require.Contains(t, queries[0], `"age" integer NOT NULL DEFAULT 0,`)
+	require.Contains(t, queries[0], `"children" integer NOT NULL`)
+	require.NotContains(t, queries[0], "PRIMARY KEY (")
+	require.NotContains(t, queries[0], "ENGINE")
+
+	require.Contains(t, SQLAll(), "CREATE TABLE IF NOT EXISTS `person` (\n    `id` bigint NOT NULL AUTO_INCREMENT,")
+
+	type badModel struct {
+		ID    int `orm:"pk"`
+		Inner struct{ A int }
+	}
+	mi = newModelInfo(reflect.ValueOf(&badModel{}))
+	require.Panics(t, func() { mi.getCreateTableSQL(sqlbuilder.MySQL) }, "unsupported type")
+}
+
+func TestTableIndex(t *testing.T) {
+	mi := newModelInfo(reflect.ValueOf(&indexModel{}))
+	mi.table = "index_model"
+
+	require.Len(t, mi.indexes, 3)
+	require.Equal(t, "idx_index_model_status", mi.getIndexName(mi.indexes[0]))
+	require.Equal(t, "uniq_index_model_code_user_id", mi.getIndexName(mi.indexes[1]))
+	require.Equal(t, []string{"user_id", "created"}, mi.indexes[2].columns())
+
+	queries := mi.getCreateTableSQL(sqlbuilder.MySQL)
+	require.Equal(t, []string{
+		"CREATE INDEX `idx_index_model_status` ON `index_model` (`status`)",
+		"CREATE UNIQUE INDEX `uniq_index_model_code_user_id` ON `index_model` (`code`, `user_id`)",
+		"CREATE INDEX `idx_index_model_user_id_created` ON `index_model` (`user_id`, `created`)",
+	}, queries[1:])
+
+	require.Panics(t, func() { newModelInfo(reflect.ValueOf(&wrongIndexModel{})) }, "wrong field of index")
+}
+
+func TestDecimalColumn(t *testing.T) {
+	mi := newModelInfo(reflect.ValueOf(&decimalModel{}))
+	mi.table = "decimal_model"
+
+	query := mi.getCreateTableSQL(sqlbuilder.MySQL)[0]
+	require.Contains(t, query, "    `price` decimal(10,2) NOT NULL,\n")
+	require.Contains(t, query, "    `amount` decimal(6,3) NULL,\n")
+	require.Contains(t, query, "    `updated` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,\n", "readonly column is created")
+
+	query = mi.getCreateTableSQL(sqlbuilder.PostgreSQL)[0]
+	require.Contains(t, query, `"price" numeric(10,2) NOT NULL,`)
+	require.Equal(t, "numeric(10,2)", normalizeColumnType(sqlbuilder.PostgreSQL, "NUMERIC(10,2)"))
+
+	for _, tag := range []string{
+		`orm:"digits(10)"`,
+		`orm:"decimals(2)"`,
+		`orm:"digits(2);decimals(3)"`,
+		`orm:"digits(0);decimals(0)"`,
+		`orm:"digits(x);decimals(2)"`,
+	} {
+		typ := reflect.StructOf([]reflect.StructField{
+			{Name: "ID", Type: reflect.TypeOf(0), Tag: `orm:"pk"`},
+			{Name: "Price", Type: reflect.TypeOf(0.0), Tag: reflect.StructTag(tag)},
+		})
+		require.Panics(t, func() { newModelInfo(reflect.New(typ)) }, tag)
+	}
+}
@@ -3,6 +3,8 @@ package orm
 import (
 	"errors"
 	"fmt"
+	"sort"
+	"strings"
 )
 
 var (
@@ -24,9 +26,19 @@ var (
 	// ErrStmtClosed indicates stmt already closed
 	ErrStmtClosed = errors.New("<QuerySeter> stmt already closed")
 
-	// ErrTableSuffixNotSameInBatchInsert indicates table suffix not same in batch insertion
+	// ErrTableSuffixNotSameInBatchInsert indicates table suffix not same in batch insertion.
+	// Deprecated: InsertMulti groups the models by table suffix now.
 	ErrTableSuffixNotSameInBatchInsert = errors.New("<Ormer> table suffix not same in batch insert")
 
+	// ErrCrossDBTransaction indicates the operation in transaction is routed to another database
+	ErrCrossDBTransaction = errors.New("<Ormer> cannot operate on another database in transaction")
+
+	// ErrMigrationLocked indicates the migrations of database are being run by another runner
+	ErrMigrationLocked = errors.New("<Migrator> migrations are locked by another runner")
+
+	// ErrInvalidCursor indicates the pagination cursor is malformed or tampered
+	ErrInvalidCursor = errors.New("<QuerySeter> invalid pagination cursor")
+
 	// ErrNotImplement indicates function not implemented
 	ErrNotImplement = errors.New("have not implement")
 )
@@ -35,3 +47,48 @@ var (
 func ErrNoTableSuffix(table string) error {
 	return fmt.Errorf("table %s is sharded but no suffix provided", table)
 }
+
+// ErrMultiShards indicates the filter of shard key matches more than one shard,
+// which can be queried by AllShards, or shard by shard with WithSuffix
+func ErrMultiShards(field, suffix1, suffix2 string) error {
+	return fmt.Errorf("<QuerySeter> values of shard key `%s` are in more than one shard: %s, %s, use AllShards or WithSuffix",
+		field, suffix1, suffix2)
+}
+
+// ErrMigrationModified indicates the applied migration is changed after it's applied
+func ErrMigrationModified(name string) error {
+	return fmt.Errorf("<Migrator> migration %s is modified after applied, checksum mismatch", name)
+}
+
+// ErrMigrationNotFound indicates the applied migration is not registered, which cannot be reverted
+func ErrMigrationNotFound(name string) error {
+	return fmt.Errorf("<Migrator> migration %s is applied but not registered", name)
+}
+
+// FieldError indicates the value of field is invalid for its column, e.g. longer than the size tag
+type FieldError struct {
+	// Field is the full name of field, e.g. github.com/me/app/models.User.Name
+	Field  string
+	Reason string
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("<Ormer> field %s %s", e.Field, e.Reason)
+}
+
+// ShardErrors indicates the errors of shards in AllShards query, keyed by table suffix
+type ShardErrors map[string]error
+
+func (e ShardErrors) Error() string {
+	suffixes := make([]string, 0, len(e))
+	for suffix := range e {
+		suffixes = append(suffixes, suffix)
+	}
+	sort.Strings(suffixes)
+
+	msgs := make([]string, len(suffixes))
+	for i, suffix := range suffixes {
+		msgs[i] = fmt.Sprintf("shard %s: %v", suffix, e[suffix])
+	}
+	return "<QuerySeter.AllShards> " + strings.Join(msgs, "; ")
+}
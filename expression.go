@@ -0,0 +1,83 @@
+package orm
+
+import (
+	"fmt"
+
+	"github.com/std0d9k81/orm/sqlbuilder"
+)
+
+// FExpr is the expression of fields, it's rendered as the quoted columns instead of a bound parameter.
+type FExpr struct {
+	name  string
+	op    operator
+	left  interface{}
+	right interface{}
+}
+
+// F return the expression of field name, it can be used as the args of Condition and the values of Params.
+// usage:
+//	qs.Filter("Balance__lt", orm.F("CreditLimit"))
+//	qs.Update(orm.Params{
+//		"Total": orm.F("Qty").Mul(orm.F("Price")),
+//	})
+func F(name string) *FExpr {
+	if name == "" {
+		panic(fmt.Errorf("orm.F field name cannot empty"))
+	}
+	return &FExpr{name: name}
+}
+
+// Add return the expression of e + value, value is a *FExpr or a bound parameter
+func (e *FExpr) Add(value interface{}) *FExpr {
+	return &FExpr{op: ColAdd, left: e, right: value}
+}
+
+// Sub return the expression of e - value, value is a *FExpr or a bound parameter
+func (e *FExpr) Sub(value interface{}) *FExpr {
+	return &FExpr{op: ColSub, left: e, right: value}
+}
+
+// Mul return the expression of e * value, value is a *FExpr or a bound parameter
+func (e *FExpr) Mul(value interface{}) *FExpr {
+	return &FExpr{op: ColMul, left: e, right: value}
+}
+
+// Div return the expression of e / value, value is a *FExpr or a bound parameter
+func (e *FExpr) Div(value interface{}) *FExpr {
+	return &FExpr{op: ColDiv, left: e, right: value}
+}
+
+// build return the builder of expression, the fields are resolved through mi.
+// the builder is compiled into the args of outer query, so the placeholders are numbered correctly.
+func (e *FExpr) build(mi *modelInfo, flavor sqlbuilder.Flavor) sqlbuilder.Builder {
+	if e.name != "" {
+		fi, ok := mi.fields.GetByAny(e.name)
+		if !ok {
+			panic(fmt.Errorf("wrong db field/column name `%s` for model `%s`", e.name, mi.fullName))
+		}
+		return sqlbuilder.Buildf("%v", sqlbuilder.Raw(quote(fi.column)))
+	}
+
+	var symbol string
+	switch e.op {
+	case ColAdd:
+		symbol = "+"
+	case ColSub:
+		symbol = "-"
+	case ColMul:
+		symbol = "*"
+	case ColDiv:
+		symbol = "/"
+	default:
+		panic(fmt.Errorf("orm.F wrong operator"))
+	}
+	return sqlbuilder.Buildf("(%v "+symbol+" %v)", resolveExpr(mi, e.left, flavor), resolveExpr(mi, e.right, flavor))
+}
+
+// resolveExpr return the builder if value is a *FExpr, otherwise value is returned as is.
+func resolveExpr(mi *modelInfo, value interface{}, flavor sqlbuilder.Flavor) interface{} {
+	if e, ok := value.(*FExpr); ok {
+		return e.build(mi, flavor)
+	}
+	return value
+}
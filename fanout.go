@@ -0,0 +1,302 @@
+package orm
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultShardWorkers the max number of shards queried concurrently by AllShards and inserted by InsertMulti
+var DefaultShardWorkers = 8
+
+// ShardSuffixes return the suffixes "0" ~ "n-1" of n shards, e.g. qs.AllShards(orm.ShardSuffixes(16)...)
+func ShardSuffixes(n int) []string {
+	suffixes := make([]string, n)
+	for i := range suffixes {
+		suffixes[i] = strconv.Itoa(i)
+	}
+	return suffixes
+}
+
+// fanOut call fn with the QuerySetter of every shard concurrently, at most DefaultShardWorkers at a time.
+// the errors of shards are returned together as ShardErrors.
+func (qs *querySetter) fanOut(method string, fn func(i int, shardQs *querySetter) error) error {
+	if qs.distinct || len(qs.groups) > 0 {
+		panic(fmt.Errorf("<QuerySeter.%s> DISTINCT and GROUP BY cannot be merged across shards", method))
+	}
+
+	return qs.orm.runShards(qs.shards, func(i int, suffix string) error {
+		shardQs := *qs
+		shardQs.shards = nil
+		shardQs.tableSuffix = suffix
+		return fn(i, &shardQs)
+	})
+}
+
+// runShards call fn with every suffix concurrently, at most DefaultShardWorkers at a time, and one by one in transaction.
+// the errors of shards are returned together as ShardErrors, and the panic of shard is raised again after all shards are done.
+func (o *orm) runShards(suffixes []string, fn func(i int, suffix string) error) error {
+	workers := DefaultShardWorkers
+	if workers <= 0 || o.isTx {
+		// the queries of a transaction are on the same connection
+		workers = 1
+	}
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		errs   = ShardErrors{}
+		panics []interface{}
+		sem    = make(chan struct{}, workers)
+	)
+
+	for i, suffix := range suffixes {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, suffix string) {
+			defer func() {
+				if r := recover(); r != nil {
+					mu.Lock()
+					panics = append(panics, r)
+					mu.Unlock()
+				}
+				<-sem
+				wg.Done()
+			}()
+
+			if err := fn(i, suffix); err != nil {
+				mu.Lock()
+				errs[suffix] = err
+				mu.Unlock()
+			}
+		}(i, suffix)
+	}
+	wg.Wait()
+
+	if len(panics) > 0 {
+		panic(panics[0])
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// countShards return the sum of Count() of all shards
+func (qs *querySetter) countShards() (int64, error) {
+	counts := make([]int64, len(qs.shards))
+	err := qs.fanOut("Count", func(i int, shardQs *querySetter) (err error) {
+		counts[i], err = shardQs.Count()
+		return
+	})
+	return sumInt64(counts), err
+}
+
+// existShards return true if any shard has rows
+func (qs *querySetter) existShards() (bool, error) {
+	exists := make([]bool, len(qs.shards))
+	err := qs.fanOut("Exist", func(i int, shardQs *querySetter) (err error) {
+		exists[i], err = shardQs.Exist()
+		return
+	})
+	for _, exist := range exists {
+		if exist {
+			return true, err
+		}
+	}
+	return false, err
+}
+
+// updateShards execute Update on all shards, return the sum of affected rows
+func (qs *querySetter) updateShards(params Params) (int64, error) {
+	nums := make([]int64, len(qs.shards))
+	err := qs.fanOut("Update", func(i int, shardQs *querySetter) (err error) {
+		nums[i], err = shardQs.Update(params)
+		return
+	})
+	return sumInt64(nums), err
+}
+
+// deleteShards execute Delete on all shards, return the sum of affected rows
+func (qs *querySetter) deleteShards() (int64, error) {
+	nums := make([]int64, len(qs.shards))
+	err := qs.fanOut("Delete", func(i int, shardQs *querySetter) (err error) {
+		nums[i], err = shardQs.Delete()
+		return
+	})
+	return sumInt64(nums), err
+}
+
+// readShards read the rows of all shards into container, the rows are merged, sorted by orders,
+// and then OFFSET and LIMIT are applied. every shard reads at most offset + limit rows.
+func (qs *querySetter) readShards(method string, container interface{}, selectNames []string) error {
+	val := reflect.ValueOf(container)
+	ind := reflect.Indirect(val)
+	if val.Kind() != reflect.Ptr || ind.Kind() != reflect.Slice {
+		panic(fmt.Errorf("<QuerySeter.%s> wrong object type `%s` for rows scan, need *[]*%s or *[]%s",
+			method, val.Type(), qs.mi.fullName, qs.mi.fullName))
+	}
+
+	less := qs.mi.getRowsLess(method, qs.orders, qs.annotations)
+
+	results := make([]reflect.Value, len(qs.shards))
+	err := qs.fanOut(method, func(i int, shardQs *querySetter) error {
+		if qs.limit > 0 {
+			shardQs.limit = qs.offset + qs.limit
+		}
+		shardQs.offset = 0
+
+		result := reflect.New(ind.Type())
+		db, err := shardQs.routeDB()
+		if err != nil {
+			return err
+		}
+		if err = qs.mi.ReadBatch(qs.orm.ctx, db, shardQs, shardQs.cond, result.Interface(), selectNames); err != nil {
+			return err
+		}
+		results[i] = result.Elem()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	rows := reflect.MakeSlice(ind.Type(), 0, 0)
+	for _, result := range results {
+		rows = reflect.AppendSlice(rows, result)
+	}
+
+	if less != nil {
+		sort.SliceStable(rows.Interface(), func(i, j int) bool {
+			return less(reflect.Indirect(rows.Index(i)), reflect.Indirect(rows.Index(j)))
+		})
+	}
+
+	start, end := qs.offset, rows.Len()
+	if start > end {
+		start = end
+	}
+	if qs.limit > 0 && start+qs.limit < end {
+		end = start + qs.limit
+	}
+	ind.Set(rows.Slice(start, end))
+	return nil
+}
+
+// oneShards read one row of all shards into container
+func (qs *querySetter) oneShards(container interface{}, selectNames []string) error {
+	val := reflect.ValueOf(container)
+	if val.Kind() != reflect.Ptr || val.Elem().Kind() != reflect.Struct {
+		panic(fmt.Errorf("<QuerySeter.One> wrong object type `%s` for rows scan, need *%s", val.Type(), qs.mi.fullName))
+	}
+
+	rows := reflect.New(reflect.SliceOf(val.Type()))
+	if err := qs.readShards("One", rows.Interface(), selectNames); err != nil {
+		return err
+	}
+	if rows.Elem().Len() == 0 {
+		return ErrNoRows
+	}
+	val.Elem().Set(rows.Elem().Index(0).Elem())
+	return nil
+}
+
+// getRowsLess return the func to compare rows by orders in memory, nil if no orders.
+// only fields and annotations scanned to fields can be ordered across shards.
+func (mi *modelInfo) getRowsLess(method string, orders []string, annotations []annotation) func(a, b reflect.Value) bool {
+	if len(orders) == 0 {
+		return nil
+	}
+
+	indexes := make([][]int, len(orders))
+	descs := make([]bool, len(orders))
+	for i, order := range orders {
+		switch order[0] {
+		case '-':
+			descs[i] = true
+			order = order[1:]
+		case '+':
+			order = order[1:]
+		}
+
+		fi, ok := mi.fields.GetByAny(order)
+		if !ok || strings.Contains(order, ExprSep) {
+			if isAnnotation(annotations, order) {
+				panic(fmt.Errorf("<QuerySeter.%s> annotation `%s` is not a field, cannot be ordered across shards", method, order))
+			}
+			panic(fmt.Errorf("<QuerySeter.%s> `%s` cannot be ordered across shards", method, order))
+		}
+		indexes[i] = fi.fieldIndex
+	}
+
+	return func(a, b reflect.Value) bool {
+		for i, index := range indexes {
+			c := compareValues(a.FieldByIndex(index), b.FieldByIndex(index))
+			if c == 0 {
+				continue
+			}
+			if descs[i] {
+				return c > 0
+			}
+			return c < 0
+		}
+		return false
+	}
+}
+
+// compareValues return -1, 0, 1 if a is less than, equal to, greater than b.
+// nil pointer is less than any value, like NULL in ascending order of MySQL.
+func compareValues(a, b reflect.Value) int {
+	if a.Kind() == reflect.Ptr {
+		switch {
+		case a.IsNil() && b.IsNil():
+			return 0
+		case a.IsNil():
+			return -1
+		case b.IsNil():
+			return 1
+		}
+		a, b = a.Elem(), b.Elem()
+	}
+
+	switch a.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return compareOrdered(a.Int() < b.Int(), a.Int() > b.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return compareOrdered(a.Uint() < b.Uint(), a.Uint() > b.Uint())
+	case reflect.Float32, reflect.Float64:
+		return compareOrdered(a.Float() < b.Float(), a.Float() > b.Float())
+	case reflect.String:
+		return strings.Compare(a.String(), b.String())
+	case reflect.Bool:
+		return compareOrdered(!a.Bool() && b.Bool(), a.Bool() && !b.Bool())
+	}
+
+	if ta, ok := a.Interface().(time.Time); ok {
+		tb := b.Interface().(time.Time)
+		return compareOrdered(ta.Before(tb), ta.After(tb))
+	}
+	return strings.Compare(fmt.Sprint(a.Interface()), fmt.Sprint(b.Interface()))
+}
+
+func compareOrdered(less, greater bool) int {
+	switch {
+	case less:
+		return -1
+	case greater:
+		return 1
+	}
+	return 0
+}
+
+func sumInt64(values []int64) int64 {
+	var sum int64
+	for _, v := range values {
+		sum += v
+	}
+	return sum
+}
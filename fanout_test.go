@@ -0,0 +1,82 @@
+package orm
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFanOut(t *testing.T) {
+	qs := &querySetter{orm: &orm{}, shards: ShardSuffixes(20)}
+
+	var running, maxRunning int32
+	suffixes := make([]string, len(qs.shards))
+	err := qs.fanOut("Count", func(i int, shardQs *querySetter) error {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			peak := atomic.LoadInt32(&maxRunning)
+			if n <= peak || atomic.CompareAndSwapInt32(&maxRunning, peak, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+
+		suffixes[i] = shardQs.tableSuffix
+		if i%7 == 3 {
+			return errors.New("failed")
+		}
+		return nil
+	})
+	require.Equal(t, ShardSuffixes(20), suffixes)
+	require.LessOrEqual(t, int(maxRunning), DefaultShardWorkers, "workers are bounded")
+
+	shardErrs, ok := err.(ShardErrors)
+	require.True(t, ok)
+	require.Len(t, shardErrs, 3)
+	require.EqualError(t, err, "<QuerySeter.AllShards> shard 10: failed; shard 17: failed; shard 3: failed")
+
+	require.Panics(t, func() {
+		// nolint:errcheck
+		qs.fanOut("Count", func(i int, shardQs *querySetter) error { panic("wrong field") })
+	}, "panic of shard")
+	require.Panics(t, func() {
+		// nolint:errcheck
+		(&querySetter{orm: &orm{}, shards: ShardSuffixes(2), groups: []string{"Age"}}).fanOut("Count", nil)
+	}, "group by across shards")
+	require.Panics(t, func() { qs.Rows() }, "rows across shards")
+}
+
+func TestRowsLess(t *testing.T) {
+	mi := newModelInfo(reflect.ValueOf(&shardedPerson{}))
+
+	persons := []shardedPerson{
+		{PersonID: 1, Name: "b", Age: 10},
+		{PersonID: 2, Name: "a", Age: 20},
+		{PersonID: 3, Name: "c", Age: 10},
+		{PersonID: 4, Name: "a", Age: 10},
+	}
+	less := mi.getRowsLess("All", []string{"-Age", "name"}, nil)
+	sort.SliceStable(persons, func(i, j int) bool {
+		return less(reflect.ValueOf(persons[i]), reflect.ValueOf(persons[j]))
+	})
+	ids := make([]int64, len(persons))
+	for i, p := range persons {
+		ids[i] = p.PersonID
+	}
+	require.Equal(t, []int64{2, 4, 1, 3}, ids)
+
+	require.Nil(t, mi.getRowsLess("All", nil, nil))
+	require.Panics(t, func() { mi.getRowsLess("All", []string{"score"}, []annotation{{alias: "score", expr: F("Age")}}) })
+	require.Panics(t, func() { mi.getRowsLess("All", []string{"Name__json__a"}, nil) })
+
+	now := time.Now()
+	require.Equal(t, -1, compareValues(reflect.ValueOf(now), reflect.ValueOf(now.Add(time.Second))))
+	require.Equal(t, 1, compareValues(reflect.ValueOf(2.5), reflect.ValueOf(1.5)))
+	require.Equal(t, -1, compareValues(reflect.ValueOf((*int)(nil)), reflect.ValueOf(new(int))))
+}
@@ -1,9 +1,13 @@
 package orm
 
 import (
+	"database/sql"
 	"errors"
 	"fmt"
+	"math"
 	"reflect"
+	"strconv"
+	"unicode/utf8"
 
 	"github.com/std0d9k81/dynamic"
 )
@@ -24,6 +28,16 @@ type fieldInfo struct {
 	json          bool
 	jsonOmitEmpty bool
 	dynamic       bool
+	size          int
+	digits        int
+	decimals      int
+	dbType        string
+	null          bool
+	initial       string
+	hasInitial    bool
+	index         bool
+	unique        bool
+	readonly      bool
 }
 
 // new field info
@@ -58,6 +72,23 @@ func newFieldInfo(mi *modelInfo, field reflect.Value, sf reflect.StructField, mN
 	fi.pk = attrs["pk"]
 	fi.auto = attrs["auto"]
 	fi.json = attrs["json"]
+	fi.dbType = tags["type"]
+	fi.null = attrs["null"]
+	fi.initial, fi.hasInitial = tags["default"]
+	fi.index = attrs["index"]
+	fi.unique = attrs["unique"]
+	fi.readonly = attrs["readonly"]
+	if size, ok := tags["size"]; ok {
+		if fi.size, err = strconv.Atoi(size); err != nil || fi.size <= 0 {
+			return nil, fmt.Errorf("wrong size `%s`", size)
+		}
+	}
+	if err = fi.parseDecimal(tags); err != nil {
+		return nil, err
+	}
+	if err = fi.checkTags(); err != nil {
+		return nil, err
+	}
 	if tags["json"] == "omitempty" {
 		fi.jsonOmitEmpty = true
 	}
@@ -74,3 +105,108 @@ func newFieldInfo(mi *modelInfo, field reflect.Value, sf reflect.StructField, mN
 
 	return fi, nil
 }
+
+// parseDecimal parse the digits and decimals tags, which must be specified together
+func (fi *fieldInfo) parseDecimal(tags map[string]string) (err error) {
+	digits, hasDigits := tags["digits"]
+	decimals, hasDecimals := tags["decimals"]
+	if !hasDigits && !hasDecimals {
+		return nil
+	}
+	if !hasDigits || !hasDecimals {
+		return fmt.Errorf("digits and decimals must be specified together")
+	}
+	if fi.digits, err = strconv.Atoi(digits); err != nil || fi.digits <= 0 || fi.digits > 65 {
+		return fmt.Errorf("wrong digits `%s`", digits)
+	}
+	if fi.decimals, err = strconv.Atoi(decimals); err != nil || fi.decimals < 0 || fi.decimals > fi.digits {
+		return fmt.Errorf("wrong decimals `%s`", decimals)
+	}
+	return nil
+}
+
+// checkTags check the tags are compatible with each other and the type of field
+func (fi *fieldInfo) checkTags() error {
+	kind := fi.getKind()
+	switch {
+	case fi.size > 0 && kind != reflect.String:
+		return fmt.Errorf("size tag is only for string field")
+	case fi.digits > 0 && kind != reflect.String && kind != reflect.Float32 && kind != reflect.Float64:
+		return fmt.Errorf("digits and decimals tags are only for float or string field")
+	case fi.readonly && fi.pk:
+		return fmt.Errorf("pk field cannot be readonly")
+	case fi.hasInitial && fi.auto:
+		return fmt.Errorf("auto field cannot have default")
+	}
+	return nil
+}
+
+// getKind return the kind of value of field, the pointer and sql.Null* are the kind of their value
+func (fi *fieldInfo) getKind() reflect.Kind {
+	typ := fi.sf.Type
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	switch typ {
+	case nullStringType:
+		return reflect.String
+	case nullInt64Type:
+		return reflect.Int64
+	case nullFloat64Type:
+		return reflect.Float64
+	case nullBoolType:
+		return reflect.Bool
+	}
+	return typ.Kind()
+}
+
+// validate check the value of field against the size, digits and decimals tags, NULL is always valid
+func (fi *fieldInfo) validate(value reflect.Value) error {
+	if value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+	switch v := value.Interface().(type) {
+	case sql.NullString:
+		if !v.Valid {
+			return nil
+		}
+		value = reflect.ValueOf(v.String)
+	case sql.NullFloat64:
+		if !v.Valid {
+			return nil
+		}
+		value = reflect.ValueOf(v.Float64)
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		s := value.String()
+		if fi.size > 0 && utf8.RuneCountInString(s) > fi.size {
+			return &FieldError{Field: fi.fullName, Reason: fmt.Sprintf("is longer than size %d", fi.size)}
+		}
+		if fi.digits > 0 {
+			f, err := strconv.ParseFloat(s, 64)
+			if err != nil {
+				return &FieldError{Field: fi.fullName, Reason: fmt.Sprintf("%q is not a decimal", s)}
+			}
+			return fi.validateDecimal(f)
+		}
+	case reflect.Float32, reflect.Float64:
+		if fi.digits > 0 {
+			return fi.validateDecimal(value.Float())
+		}
+	}
+	return nil
+}
+
+// validateDecimal check f fits in decimal(digits,decimals) after rounded to decimals
+func (fi *fieldInfo) validateDecimal(f float64) error {
+	scale := math.Pow10(fi.decimals)
+	if math.IsNaN(f) || math.Abs(math.Round(f*scale)/scale) >= math.Pow10(fi.digits-fi.decimals) {
+		return &FieldError{Field: fi.fullName, Reason: fmt.Sprintf("%v is out of range of decimal(%d,%d)", f, fi.digits, fi.decimals)}
+	}
+	return nil
+}
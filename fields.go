@@ -12,6 +12,7 @@ type fields struct {
 	fieldsDB  []*fieldInfo
 	orders    []string
 	dbcols    []string
+	writecols []string
 }
 
 // Add add fieldInfo to fields
@@ -25,6 +26,9 @@ func (f *fields) Add(fi *fieldInfo) (added bool) {
 	}
 	f.orders = append(f.orders, fi.column)
 	f.dbcols = append(f.dbcols, fi.column)
+	if !fi.readonly {
+		f.writecols = append(f.writecols, fi.column)
+	}
 	f.fieldsDB = append(f.fieldsDB, fi)
 	return true
 }
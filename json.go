@@ -4,9 +4,13 @@ import (
 	"database/sql/driver"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"reflect"
+	"strconv"
+	"strings"
 
 	"github.com/std0d9k81/dynamic"
+	"github.com/std0d9k81/orm/sqlbuilder"
 )
 
 // JSONValue is the json wrapper
@@ -58,3 +62,166 @@ func (jv *JSONValue) Scan(value interface{}) error {
 		return errors.New("invalid type for json raw data")
 	}
 }
+
+// checkJSONField panics if fi is not a json field
+func checkJSONField(fi *fieldInfo) {
+	if !fi.json {
+		panic(fmt.Errorf("field `%s` is not a json field", fi.fullName))
+	}
+}
+
+// getJSONExtractSQL return the expression of value at path of json field fi
+func getJSONExtractSQL(fi *fieldInfo, path []string, cond *sqlbuilder.Cond) string {
+	checkJSONField(fi)
+	if len(path) == 0 {
+		panic(fmt.Errorf("json path of field `%s` cannot empty", fi.fullName))
+	}
+	return cond.JSONExtract(quoteFlavor(cond.Args.Flavor, fi.column), path...)
+}
+
+// kinds of json mutation
+const (
+	jsonSet = iota
+	jsonRemove
+	jsonArrayAppend
+)
+
+type jsonOp struct {
+	kind  int
+	path  []string
+	value interface{}
+}
+
+// jsonMutation is the partial update of json field, the ops are applied in order
+type jsonMutation struct {
+	ops []jsonOp
+}
+
+// JSONSet set the value at path of json field, other keys are kept. usage:
+//	num, err := qs.Update(orm.JSONSet("Content", "$.user.name", "slene"))
+//	//sql-> UPDATE ... SET `content` = JSON_SET(..., '$.user.name', CAST(? AS JSON))
+// path is like "$.a.b[0]", the keys must be identifiers.
+func JSONSet(field string, path string, value interface{}) Params {
+	return newJSONMutation(field, jsonOp{kind: jsonSet, path: parseJSONPath(path), value: value})
+}
+
+// JSONRemove remove the value at path of json field. usage:
+//	num, err := qs.Update(orm.JSONRemove("Content", "$.user.name"))
+func JSONRemove(field string, path string) Params {
+	return newJSONMutation(field, jsonOp{kind: jsonRemove, path: parseJSONPath(path)})
+}
+
+// JSONArrayAppend append the value to the array at path of json field. usage:
+//	num, err := qs.Update(orm.JSONArrayAppend("Content", "$.tags", "vip"))
+func JSONArrayAppend(field string, path string, value interface{}) Params {
+	return newJSONMutation(field, jsonOp{kind: jsonArrayAppend, path: parseJSONPath(path), value: value})
+}
+
+func newJSONMutation(field string, op jsonOp) Params {
+	if field == "" {
+		panic(fmt.Errorf("orm.JSON mutator field cannot empty"))
+	}
+	if op.kind != jsonRemove {
+		data, err := json.Marshal(op.value)
+		if err != nil {
+			panic(fmt.Errorf("orm.JSON mutator value of field `%s`: %v", field, err))
+		}
+		op.value = string(data)
+	}
+	return Params{field: &jsonMutation{ops: []jsonOp{op}}}
+}
+
+// parseJSONPath parse path like "$.a.b[0]" to keys, e.g. ["a", "b", "0"]
+func parseJSONPath(path string) []string {
+	if !strings.HasPrefix(path, "$") || len(path) == 1 {
+		panic(fmt.Errorf("invalid json path `%s`, it should be like `$.a.b[0]`", path))
+	}
+
+	var keys []string
+	for rest := path[1:]; rest != ""; {
+		var key string
+		switch rest[0] {
+		case '.':
+			end := strings.IndexAny(rest[1:], ".[")
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			key, rest = rest[1:end+1], rest[end+1:]
+			if !isJSONPathKey(key) {
+				panic(fmt.Errorf("invalid json path `%s`, key `%s` should be an identifier", path, key))
+			}
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				panic(fmt.Errorf("invalid json path `%s`, `[` is not closed", path))
+			}
+			key, rest = rest[1:end], rest[end+1:]
+			if _, err := strconv.ParseUint(key, 10, 64); err != nil {
+				panic(fmt.Errorf("invalid json path `%s`, index `%s` should be a number", path, key))
+			}
+		default:
+			panic(fmt.Errorf("invalid json path `%s`, it should be like `$.a.b[0]`", path))
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func isJSONPathKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for i, r := range key {
+		if r != '_' && (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') && (i == 0 || r < '0' || r > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// build return the builder of json document after the mutations on column
+func (m *jsonMutation) build(column string, flavor sqlbuilder.Flavor) sqlbuilder.Builder {
+	column = quoteFlavor(flavor, column)
+
+	if flavor == sqlbuilder.PostgreSQL {
+		// the column is text or jsonb, the empty string of omitempty json fields is compared as text
+		doc := sqlbuilder.Buildf("COALESCE(CAST(NULLIF(CAST(%v AS TEXT), '') AS JSONB), '{}')", sqlbuilder.Raw(column))
+		for _, op := range m.ops {
+			path := "'{" + strings.Join(op.path, ",") + "}'"
+			switch op.kind {
+			case jsonSet:
+				doc = sqlbuilder.Buildf("jsonb_set(%v, "+path+", CAST(%v AS JSONB))", doc, op.value)
+			case jsonRemove:
+				doc = sqlbuilder.Buildf("(%v #- "+path+")", doc)
+			case jsonArrayAppend:
+				doc = sqlbuilder.Buildf("jsonb_set(%v, "+path+", COALESCE(%v #> "+path+", '[]') || jsonb_build_array(CAST(%v AS JSONB)))",
+					doc, doc, op.value)
+			}
+		}
+		return doc
+	}
+
+	// empty string is stored for omitempty json fields
+	doc := sqlbuilder.Buildf("COALESCE(NULLIF(%v, ''), '{}')", sqlbuilder.Raw(column))
+	for _, op := range m.ops {
+		path := "'$"
+		for _, key := range op.path {
+			if key[0] >= '0' && key[0] <= '9' {
+				path += "[" + key + "]"
+			} else {
+				path += "." + key
+			}
+		}
+		path += "'"
+
+		switch op.kind {
+		case jsonSet:
+			doc = sqlbuilder.Buildf("JSON_SET(%v, "+path+", CAST(%v AS JSON))", doc, op.value)
+		case jsonRemove:
+			doc = sqlbuilder.Buildf("JSON_REMOVE(%v, "+path+")", doc)
+		case jsonArrayAppend:
+			doc = sqlbuilder.Buildf("JSON_ARRAY_APPEND(%v, "+path+", CAST(%v AS JSON))", doc, op.value)
+		}
+	}
+	return doc
+}
@@ -1,9 +1,11 @@
 package orm
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/std0d9k81/dynamic"
+	"github.com/std0d9k81/orm/sqlbuilder"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 )
@@ -98,6 +100,31 @@ func TestJSON(t *testing.T) {
 	require.Equal(t, []int{1, 3, 5}, bdata.Values, "check aObjRead.Content.Values")
 	bdataFromPtr := bObjRead.ContentPtr.Data.Value.(*bData)
 	require.Equal(t, []int{1, 3, 5}, bdataFromPtr.Values, "check aObjRead.ContentPtr.Values")
+
+	// json path filter
+	qs := db.QueryTable(new(jsonModel))
+	count, err := qs.Filter("Content__json__type", "a").Filter("Content__json__data__value__gte", 10).Count()
+	require.NoError(t, err, "count by json path")
+	require.Equal(t, int64(1), count, "count by json path != 1")
+
+	count, err = qs.Filter("Content__json__data__items__json_contains", 3).Count()
+	require.NoError(t, err, "count by json contains")
+	require.Equal(t, int64(1), count, "count by json contains != 1")
+
+	// json partial update
+	num, err := db.(ParamsUpdater).UpdateParams(&jsonModel{ID: bId}, JSONArrayAppend("Content", "$.data.items", 7))
+	require.NoError(t, err, "append json array")
+	require.Equal(t, int64(1), num, "append json array rows != 1")
+
+	num, err = qs.Filter("ID", aId).Update(JSONSet("ContentPtr", "$.data.value", 20))
+	require.NoError(t, err, "set json value")
+	require.Equal(t, int64(1), num, "set json value rows != 1")
+
+	require.NoError(t, db.Read(aObjRead), "read aObj after update")
+	require.Equal(t, 20, aObjRead.ContentPtr.Data.Value.(*aData).Value, "check aObjRead.ContentPtr.Value after update")
+	require.Equal(t, 10, aObjRead.Content.Data.Value.(*aData).Value, "check aObjRead.Content.Value after update")
+	require.NoError(t, db.Read(bObjRead), "read bObj after update")
+	require.Equal(t, []int{1, 3, 5, 7}, bObjRead.Content.Data.Value.(*bData).Values, "check bObjRead.Content.Values after update")
 }
 
 type mapJsonModel struct {
@@ -126,3 +153,46 @@ func TestJSONMap(t *testing.T) {
 	require.NoError(t, err, "read map json obj")
 	require.Equal(t, content, mapObjRead.Content, "check map json content readed")
 }
+
+func TestJSONMutation(t *testing.T) {
+	mi := newModelInfo(reflect.ValueOf(&jsonModel{}))
+	mi.table = "json_model"
+
+	params := Params{"ID": 2}.Merge(
+		JSONSet("Content", "$.data.items[1]", 10),
+		JSONRemove("Content", "$.type"),
+		JSONArrayAppend("ContentPtr", "$.data.items", map[string]int{"a": 1}),
+	)
+	columns, values := mi.getParamsColumns(params)
+
+	builder := sqlbuilder.NewUpdateBuilder()
+	builder.Update(quote(mi.table)).Set(getAssignments(mi, builder, columns, values)...).Where(builder.E("`id`", 1))
+	query, args := builder.Build()
+	require.Contains(t, query, "`id` = ?")
+	require.Contains(t, query, "`content` = JSON_REMOVE(JSON_SET(COALESCE(NULLIF(`content`, ''), '{}'), '$.data.items[1]', CAST(? AS JSON)), '$.type')")
+	require.Contains(t, query, "`content_ptr` = JSON_ARRAY_APPEND(COALESCE(NULLIF(`content_ptr`, ''), '{}'), '$.data.items', CAST(? AS JSON))")
+	require.Len(t, args, 4)
+
+	builder = sqlbuilder.PostgreSQL.NewUpdateBuilder()
+	builder.Update(sqlbuilder.PostgreSQL.Quote(mi.table)).
+		Set(getAssignments(mi, builder, []string{"content"}, []interface{}{params["Content"]})...).
+		Where(builder.E(`"id"`, 1))
+	query, args = builder.Build()
+	require.Equal(t, `UPDATE "json_model" SET "content" = `+
+		`(jsonb_set(COALESCE(CAST(NULLIF(CAST("content" AS TEXT), '') AS JSONB), '{}'), '{data,items,1}', CAST($1 AS JSONB)) #- '{type}') WHERE "id" = $2`, query)
+	require.Equal(t, []interface{}{"10", 1}, args)
+
+	builder = sqlbuilder.PostgreSQL.NewUpdateBuilder()
+	builder.Update(sqlbuilder.PostgreSQL.Quote(mi.table)).
+		Set(getAssignments(mi, builder, []string{"content_ptr"}, []interface{}{params["ContentPtr"]})...)
+	query, args = builder.Build()
+	require.Equal(t, `UPDATE "json_model" SET "content_ptr" = jsonb_set(COALESCE(CAST(NULLIF(CAST("content_ptr" AS TEXT), '') AS JSONB), '{}'), '{data,items}', `+
+		`COALESCE(COALESCE(CAST(NULLIF(CAST("content_ptr" AS TEXT), '') AS JSONB), '{}') #> '{data,items}', '[]') || jsonb_build_array(CAST($1 AS JSONB)))`, query)
+	require.Equal(t, []interface{}{`{"a":1}`}, args)
+	require.NotContains(t, query, "`")
+
+	require.Panics(t, func() { mi.getParamsColumns(JSONSet("ID", "$.a", 1)) }, "not json field")
+	for _, path := range []string{"", "$", "a.b", "$.a'b", "$.a[x]", "$.a[1", "$..a", "$.1a"} {
+		require.Panics(t, func() { JSONRemove("Content", path) }, "invalid path %s", path)
+	}
+}
@@ -0,0 +1,620 @@
+package orm
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash/crc32"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/std0d9k81/kate/log/ctxzap"
+	"github.com/std0d9k81/orm/sqlbuilder"
+	"go.uber.org/zap"
+)
+
+// MigrationTable the table to record the applied migrations, it's also the name of the migration lock
+var MigrationTable = "orm_migrations"
+
+// MigrateFunc apply or revert a migration with the Ormer using the migrated database,
+// the Ormer is in transaction if the migration is transactional.
+type MigrateFunc func(o Ormer) error
+
+// migration is a versioned schema change, migrations are applied in order of name
+type migration struct {
+	name     string
+	up       MigrateFunc
+	down     MigrateFunc
+	checksum string
+	// the statements of SQL migration
+	upSQL   []string
+	downSQL []string
+	// run without transaction, e.g. CREATE INDEX CONCURRENTLY of PostgreSQL
+	noTx bool
+}
+
+var migrationCache = struct {
+	sync.RWMutex
+	cache map[string]*migration
+}{cache: make(map[string]*migration)}
+
+// registerMigration add the migration to cache, panic if the name is registered
+func registerMigration(m *migration) {
+	migrationCache.Lock()
+	defer migrationCache.Unlock()
+
+	if m.name == "" {
+		panic(fmt.Errorf("<orm.RegisterMigration> migration name cannot be empty"))
+	}
+	if m.up == nil {
+		panic(fmt.Errorf("<orm.RegisterMigration> migration %s has no up", m.name))
+	}
+	if _, ok := migrationCache.cache[m.name]; ok {
+		panic(fmt.Errorf("<orm.RegisterMigration> migration %s already registered", m.name))
+	}
+	migrationCache.cache[m.name] = m
+}
+
+// getSortedMigrations return the registered migrations in order of name
+func getSortedMigrations() []*migration {
+	migrationCache.RLock()
+	defer migrationCache.RUnlock()
+
+	migrations := make([]*migration, 0, len(migrationCache.cache))
+	for _, m := range migrationCache.cache {
+		migrations = append(migrations, m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].name < migrations[j].name })
+	return migrations
+}
+
+// RegisterMigration register the migration written in Go, the migrations are applied in order of name,
+// so the name should be prefixed with version, e.g. "20220301_add_user_email".
+// down can be nil if the migration is irreversible.
+// the migration is run in transaction by the databases support transactional DDL, i.e. PostgreSQL and SQLite.
+func RegisterMigration(name string, up, down MigrateFunc) {
+	registerMigration(&migration{name: name, up: up, down: down})
+}
+
+// RegisterMigrationSQL register the migration written in SQL, source has the up and down sections:
+//	-- +migrate Up
+//	CREATE TABLE user (id bigint NOT NULL, PRIMARY KEY (id));
+//	-- +migrate Down
+//	DROP TABLE user;
+// a statement ends with the line ending with semicolon, the statement containing semicolons,
+// e.g. the body of function, is wrapped by "-- +migrate StatementBegin" and "-- +migrate StatementEnd".
+// "-- +migrate Up notransaction" run the migration without transaction.
+// the checksum of source is recorded, and Up refuses to run if an applied migration is modified.
+func RegisterMigrationSQL(name, source string) {
+	m, err := newSQLMigration(name, source)
+	if err != nil {
+		panic(fmt.Errorf("<orm.RegisterMigrationSQL> %v", err))
+	}
+	registerMigration(m)
+}
+
+// LoadMigrations register the *.sql files in dir as migrations by RegisterMigrationSQL,
+// the file name without extension is the migration name.
+func LoadMigrations(dir string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.sql"))
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		source, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		m, err := newSQLMigration(strings.TrimSuffix(filepath.Base(file), ".sql"), string(source))
+		if err != nil {
+			return fmt.Errorf("<orm.LoadMigrations> %s: %v", file, err)
+		}
+		registerMigration(m)
+	}
+	return nil
+}
+
+// newSQLMigration parse the source of SQL migration
+func newSQLMigration(name, source string) (*migration, error) {
+	up, down, noTx, err := parseMigrationSQL(source)
+	if err != nil {
+		return nil, fmt.Errorf("migration %s: %v", name, err)
+	}
+
+	sum := sha256.Sum256([]byte(source))
+	m := &migration{
+		name:     name,
+		up:       execStatements(up),
+		checksum: hex.EncodeToString(sum[:]),
+		upSQL:    up,
+		downSQL:  down,
+		noTx:     noTx,
+	}
+	if len(down) > 0 {
+		m.down = execStatements(down)
+	}
+	return m, nil
+}
+
+// execStatements return the MigrateFunc to execute statements one by one
+func execStatements(statements []string) MigrateFunc {
+	return func(o Ormer) error {
+		for _, statement := range statements {
+			if _, err := o.Raw(statement).Exec(); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+const migrateDirective = "-- +migrate "
+
+// parseMigrationSQL split the source of SQL migration into the statements of up and down sections
+// nolint:gocyclo
+func parseMigrationSQL(source string) (up, down []string, noTx bool, err error) {
+	var (
+		section     *[]string
+		hasUp       bool
+		inStatement bool
+		buf         strings.Builder
+	)
+
+	flush := func() {
+		statement := strings.TrimSpace(buf.String())
+		buf.Reset()
+		if statement != "" {
+			*section = append(*section, statement)
+		}
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(source))
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+
+		if strings.HasPrefix(trimmed, migrateDirective) {
+			fields := strings.Fields(trimmed[len(migrateDirective):])
+			if len(fields) == 0 {
+				return nil, nil, false, fmt.Errorf("empty directive")
+			}
+			switch fields[0] {
+			case "Up", "Down":
+				if inStatement {
+					return nil, nil, false, fmt.Errorf("missing StatementEnd before %s", fields[0])
+				}
+				if section != nil && strings.TrimSpace(buf.String()) != "" {
+					return nil, nil, false, fmt.Errorf("missing semicolon at the end of statement")
+				}
+				buf.Reset()
+				if fields[0] == "Up" {
+					if hasUp {
+						return nil, nil, false, fmt.Errorf("duplicate Up section")
+					}
+					hasUp, section = true, &up
+				} else {
+					if down != nil || section == &down {
+						return nil, nil, false, fmt.Errorf("duplicate Down section")
+					}
+					section = &down
+				}
+				for _, option := range fields[1:] {
+					if option != "notransaction" {
+						return nil, nil, false, fmt.Errorf("unknown option %s", option)
+					}
+					noTx = true
+				}
+			case "StatementBegin":
+				if section == nil || inStatement {
+					return nil, nil, false, fmt.Errorf("unexpected StatementBegin")
+				}
+				if strings.TrimSpace(buf.String()) != "" {
+					return nil, nil, false, fmt.Errorf("missing semicolon at the end of statement")
+				}
+				buf.Reset()
+				inStatement = true
+			case "StatementEnd":
+				if !inStatement {
+					return nil, nil, false, fmt.Errorf("unexpected StatementEnd")
+				}
+				flush()
+				inStatement = false
+			default:
+				return nil, nil, false, fmt.Errorf("unknown directive %s", fields[0])
+			}
+			continue
+		}
+
+		if section == nil {
+			if trimmed != "" && !strings.HasPrefix(trimmed, "--") {
+				return nil, nil, false, fmt.Errorf("statement before Up section")
+			}
+			continue
+		}
+		// skip the comments between statements
+		if !inStatement && buf.Len() == 0 && (trimmed == "" || strings.HasPrefix(trimmed, "--")) {
+			continue
+		}
+
+		buf.WriteString(line)
+		buf.WriteString("\n")
+		if !inStatement && strings.HasSuffix(trimmed, ";") {
+			statement := strings.TrimSpace(buf.String())
+			buf.Reset()
+			buf.WriteString(strings.TrimSuffix(statement, ";"))
+			flush()
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, nil, false, err
+	}
+
+	switch {
+	case !hasUp:
+		return nil, nil, false, fmt.Errorf("missing Up section")
+	case inStatement:
+		return nil, nil, false, fmt.Errorf("missing StatementEnd")
+	case strings.TrimSpace(buf.String()) != "":
+		return nil, nil, false, fmt.Errorf("missing semicolon at the end of statement")
+	}
+	return up, down, noTx, nil
+}
+
+// MigrationStatus is the status of migration on database
+type MigrationStatus struct {
+	Name string
+	// Applied the migration is applied
+	Applied bool
+	// AppliedAt the time the migration is applied
+	AppliedAt time.Time
+	// Modified the migration is modified after applied
+	Modified bool
+	// Missing the migration is applied but not registered
+	Missing bool
+}
+
+// appliedMigration is the record of applied migration
+type appliedMigration struct {
+	name      string
+	checksum  string
+	appliedAt time.Time
+}
+
+// Migrator run the registered migrations on a database
+type Migrator struct {
+	ctx    context.Context
+	dbName string
+	db     *database
+}
+
+// NewMigrator create a migrator for the database registered by RegisterDB
+func NewMigrator(dbName string, logger *zap.Logger) *Migrator {
+	return &Migrator{
+		ctx:    ctxzap.ToContext(context.TODO(), logger),
+		dbName: dbName,
+		db:     getDB(dbName),
+	}
+}
+
+// Up apply all pending migrations in order, return the names of applied migrations.
+// the migration failed is not recorded, and the migrations after it are not applied.
+func (m *Migrator) Up() ([]string, error) {
+	var names []string
+	err := m.withLock(func(applied map[string]*appliedMigration) error {
+		for _, mg := range getSortedMigrations() {
+			record, ok := applied[mg.name]
+			if !ok {
+				continue
+			}
+			if mg.checksum != "" && record.checksum != mg.checksum {
+				return ErrMigrationModified(mg.name)
+			}
+		}
+
+		for _, mg := range getSortedMigrations() {
+			if _, ok := applied[mg.name]; ok {
+				continue
+			}
+			if err := m.run(mg, true); err != nil {
+				return err
+			}
+			names = append(names, mg.name)
+		}
+		return nil
+	})
+	return names, err
+}
+
+// Down revert the last n applied migrations in reverse order of applied time, return the names of reverted migrations
+func (m *Migrator) Down(n int) ([]string, error) {
+	if n <= 0 {
+		panic(fmt.Errorf("<Migrator.Down> n must be positive, but got %d", n))
+	}
+
+	var names []string
+	err := m.withLock(func(applied map[string]*appliedMigration) error {
+		for _, name := range lastApplied(applied, n) {
+			if err := m.revert(name); err != nil {
+				return err
+			}
+			names = append(names, name)
+		}
+		return nil
+	})
+	return names, err
+}
+
+// Redo revert and apply the last applied migration again, return its name, empty if no migration applied
+func (m *Migrator) Redo() (string, error) {
+	var name string
+	err := m.withLock(func(applied map[string]*appliedMigration) error {
+		names := lastApplied(applied, 1)
+		if len(names) == 0 {
+			return nil
+		}
+		if err := m.revert(names[0]); err != nil {
+			return err
+		}
+		name = names[0]
+		return m.run(getMigration(name), true)
+	})
+	return name, err
+}
+
+// Status return the status of registered migrations in order of name,
+// followed by the migrations applied but not registered.
+func (m *Migrator) Status() ([]*MigrationStatus, error) {
+	applied := make(map[string]*appliedMigration)
+	exist, err := tableExists(m.ctx, m.db.DB, m.db.flavor(), MigrationTable)
+	if err != nil {
+		return nil, err
+	}
+	if exist {
+		if applied, err = m.getApplied(); err != nil {
+			return nil, err
+		}
+	}
+
+	var statuses []*MigrationStatus
+	for _, mg := range getSortedMigrations() {
+		status := &MigrationStatus{Name: mg.name}
+		if record, ok := applied[mg.name]; ok {
+			status.Applied = true
+			status.AppliedAt = record.appliedAt
+			status.Modified = mg.checksum != "" && record.checksum != mg.checksum
+			delete(applied, mg.name)
+		}
+		statuses = append(statuses, status)
+	}
+
+	missing := make([]*MigrationStatus, 0, len(applied))
+	for _, record := range applied {
+		missing = append(missing, &MigrationStatus{Name: record.name, Applied: true, AppliedAt: record.appliedAt, Missing: true})
+	}
+	sort.Slice(missing, func(i, j int) bool { return missing[i].Name < missing[j].Name })
+	return append(statuses, missing...), nil
+}
+
+// getMigration return the registered migration by name, nil if not registered
+func getMigration(name string) *migration {
+	migrationCache.RLock()
+	defer migrationCache.RUnlock()
+	return migrationCache.cache[name]
+}
+
+// lastApplied return the names of the last n applied migrations in reverse order of applied time, then name.
+// the migration with earlier name may be applied later, e.g. it's merged from another branch.
+func lastApplied(applied map[string]*appliedMigration, n int) []string {
+	records := make([]*appliedMigration, 0, len(applied))
+	for _, record := range applied {
+		records = append(records, record)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		if !records[i].appliedAt.Equal(records[j].appliedAt) {
+			return records[i].appliedAt.After(records[j].appliedAt)
+		}
+		return records[i].name > records[j].name
+	})
+	if len(records) > n {
+		records = records[:n]
+	}
+
+	names := make([]string, len(records))
+	for i, record := range records {
+		names[i] = record.name
+	}
+	return names
+}
+
+// revert revert the applied migration by name
+func (m *Migrator) revert(name string) error {
+	mg := getMigration(name)
+	if mg == nil {
+		return ErrMigrationNotFound(name)
+	}
+	if mg.down == nil {
+		return fmt.Errorf("<Migrator> migration %s is irreversible", name)
+	}
+	return m.run(mg, false)
+}
+
+// run apply or revert the migration, and record it in the migration table.
+// the migration and its record are in the same transaction if the database supports transactional DDL.
+func (m *Migrator) run(mg *migration, up bool) (err error) {
+	fn, action := mg.up, "apply"
+	if !up {
+		fn, action = mg.down, "revert"
+	}
+	ctxzap.Extract(m.ctx).With(defaultLoggerTag).Info(action+" migration", zap.String("db", m.dbName), zap.String("name", mg.name))
+
+	o := &orm{ctx: m.ctx}
+	o.Using(m.dbName)
+
+	// DDL of MySQL is committed implicitly
+	if !mg.noTx && m.db.flavor() != sqlbuilder.MySQL {
+		if err = o.Begin(); err != nil {
+			return err
+		}
+		defer func() {
+			if err != nil {
+				o.RollbackIfNotCommitted()
+			}
+		}()
+	}
+
+	if err = fn(o); err != nil {
+		return fmt.Errorf("<Migrator> %s migration %s: %v", action, mg.name, err)
+	}
+
+	table := m.db.flavor().Quote(MigrationTable)
+	if up {
+		query := fmt.Sprintf("INSERT INTO %s (name, checksum, applied_at) VALUES (%s, %s, %s)",
+			table, m.bindVar(1), m.bindVar(2), m.bindVar(3))
+		_, err = o.db.ExecContext(m.ctx, query, mg.name, mg.checksum, time.Now().Unix())
+	} else {
+		query := fmt.Sprintf("DELETE FROM %s WHERE name = %s", table, m.bindVar(1))
+		_, err = o.db.ExecContext(m.ctx, query, mg.name)
+	}
+	if err != nil {
+		return err
+	}
+
+	if o.isTx {
+		return o.Commit()
+	}
+	return nil
+}
+
+// withLock call fn with the applied migrations when holding the migration lock of database
+func (m *Migrator) withLock(fn func(applied map[string]*appliedMigration) error) (err error) {
+	unlock, err := m.lock()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if e := unlock(); e != nil && err == nil {
+			err = e
+		}
+	}()
+
+	query := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (\n"+
+		"    name varchar(255) NOT NULL,\n"+
+		"    checksum varchar(64) NOT NULL,\n"+
+		"    applied_at bigint NOT NULL,\n"+
+		"    PRIMARY KEY (name)\n"+
+		")", m.db.flavor().Quote(MigrationTable))
+	if _, err = m.db.DB.ExecContext(m.ctx, query); err != nil {
+		return err
+	}
+
+	applied, err := m.getApplied()
+	if err != nil {
+		return err
+	}
+	return fn(applied)
+}
+
+// lock acquire the migration lock of database, return ErrMigrationLocked if it's held by another runner.
+// MySQL and PostgreSQL use the advisory lock of session, SQLite uses a lock table.
+// the lock of MySQL is server-wide, so its name includes the hash of current database.
+func (m *Migrator) lock() (func() error, error) {
+	var (
+		flavor = m.db.flavor()
+		query  string
+		key    interface{}
+	)
+	switch flavor {
+	case sqlbuilder.PostgreSQL:
+		query = "SELECT pg_try_advisory_lock($1)"
+		key = int64(crc32.ChecksumIEEE([]byte(MigrationTable)))
+	case sqlbuilder.SQLite:
+		return m.lockTable()
+	default:
+		query = "SELECT GET_LOCK(CONCAT(?, MD5(IFNULL(DATABASE(), ''))), 0) = 1"
+		key = MigrationTable + ":"
+	}
+
+	// the advisory lock is held by the connection
+	conn, err := m.db.DB.Conn(m.ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var locked bool
+	if err = conn.QueryRowContext(m.ctx, query, key).Scan(&locked); err != nil || !locked {
+		// nolint:errcheck
+		conn.Close()
+		if err == nil {
+			err = ErrMigrationLocked
+		}
+		return nil, err
+	}
+
+	return func() error {
+		// nolint:errcheck
+		defer conn.Close()
+		unlock := "SELECT RELEASE_LOCK(CONCAT(?, MD5(IFNULL(DATABASE(), ''))))"
+		if flavor == sqlbuilder.PostgreSQL {
+			unlock = "SELECT pg_advisory_unlock($1)"
+		}
+		_, err := conn.ExecContext(m.ctx, unlock, key)
+		return err
+	}, nil
+}
+
+// lockTable acquire the migration lock by inserting the only row of lock table
+func (m *Migrator) lockTable() (func() error, error) {
+	table := m.db.flavor().Quote(MigrationTable + "_lock")
+	query := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (id integer NOT NULL, PRIMARY KEY (id))", table)
+	if _, err := m.db.DB.ExecContext(m.ctx, query); err != nil {
+		return nil, err
+	}
+	if _, err := m.db.DB.ExecContext(m.ctx, fmt.Sprintf("INSERT INTO %s (id) VALUES (1)", table)); err != nil {
+		return nil, ErrMigrationLocked
+	}
+
+	return func() error {
+		_, err := m.db.DB.ExecContext(m.ctx, fmt.Sprintf("DELETE FROM %s", table))
+		return err
+	}, nil
+}
+
+// getApplied return the applied migrations recorded in the migration table
+func (m *Migrator) getApplied() (map[string]*appliedMigration, error) {
+	query := fmt.Sprintf("SELECT name, checksum, applied_at FROM %s", m.db.flavor().Quote(MigrationTable))
+	rows, err := m.db.DB.QueryContext(m.ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	// nolint:errcheck
+	defer rows.Close()
+
+	applied := make(map[string]*appliedMigration)
+	for rows.Next() {
+		var (
+			record    = &appliedMigration{}
+			appliedAt int64
+		)
+		if err = rows.Scan(&record.name, &record.checksum, &appliedAt); err != nil {
+			return nil, err
+		}
+		record.appliedAt = time.Unix(appliedAt, 0).In(DefaultTimeLoc)
+		applied[record.name] = record
+	}
+	return applied, rows.Err()
+}
+
+// bindVar return the i-th placeholder of database, starting from 1
+func (m *Migrator) bindVar(i int) string {
+	if m.db.flavor() == sqlbuilder.PostgreSQL {
+		return fmt.Sprintf("$%d", i)
+	}
+	return "?"
+}
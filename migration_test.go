@@ -0,0 +1,108 @@
+package orm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrationSQL(t *testing.T) {
+	source := `-- create the user table
+-- +migrate Up
+CREATE TABLE user (
+    id bigint NOT NULL,
+    name varchar(64) NOT NULL DEFAULT 'a;b',
+    PRIMARY KEY (id)
+);
+
+-- the trigger has semicolons in body
+-- +migrate StatementBegin
+CREATE TRIGGER user_name BEFORE INSERT ON user FOR EACH ROW
+BEGIN
+    SET NEW.name = LOWER(NEW.name);
+END;
+-- +migrate StatementEnd
+CREATE INDEX idx_user_name ON user (name);
+
+-- +migrate Down
+DROP TABLE user;
+`
+	up, down, noTx, err := parseMigrationSQL(source)
+	require.NoError(t, err)
+	require.False(t, noTx)
+	require.Equal(t, []string{
+		"CREATE TABLE user (\n    id bigint NOT NULL,\n    name varchar(64) NOT NULL DEFAULT 'a;b',\n    PRIMARY KEY (id)\n)",
+		"CREATE TRIGGER user_name BEFORE INSERT ON user FOR EACH ROW\nBEGIN\n    SET NEW.name = LOWER(NEW.name);\nEND;",
+		"CREATE INDEX idx_user_name ON user (name)",
+	}, up)
+	require.Equal(t, []string{"DROP TABLE user"}, down)
+
+	up, down, noTx, err = parseMigrationSQL("-- +migrate Up notransaction\nCREATE INDEX CONCURRENTLY idx ON t (c);\n")
+	require.NoError(t, err)
+	require.True(t, noTx)
+	require.Equal(t, []string{"CREATE INDEX CONCURRENTLY idx ON t (c)"}, up)
+	require.Nil(t, down)
+
+	for name, source := range map[string]string{
+		"no up":              "CREATE TABLE t (id int);",
+		"statement before":   "CREATE TABLE t (id int);\n-- +migrate Up\n",
+		"missing semicolon":  "-- +migrate Up\nCREATE TABLE t (id int)\n-- +migrate Down\nDROP TABLE t;",
+		"missing end":        "-- +migrate Up\n-- +migrate StatementBegin\nCREATE TABLE t (id int);\n",
+		"unexpected end":     "-- +migrate Up\n-- +migrate StatementEnd\n",
+		"duplicate up":       "-- +migrate Up\n-- +migrate Up\n",
+		"duplicate down":     "-- +migrate Up\n-- +migrate Down\n-- +migrate Down\n",
+		"unknown option":     "-- +migrate Up fast\n",
+		"unknown directive":  "-- +migrate Sideways\n",
+		"unterminated at up": "-- +migrate Down\nDROP TABLE t\n-- +migrate Up\n",
+	} {
+		_, _, _, err = parseMigrationSQL(source)
+		require.Error(t, err, name)
+	}
+
+	m, err := newSQLMigration("20220301_create_user", source)
+	require.NoError(t, err)
+	require.Len(t, m.checksum, 64)
+	require.NotNil(t, m.down)
+	m2, err := newSQLMigration("20220301_create_user", source+"\n")
+	require.NoError(t, err)
+	require.NotEqual(t, m.checksum, m2.checksum, "checksum of modified source")
+
+	_, err = newSQLMigration("20220302_bad", "-- DROP TABLE user;")
+	require.EqualError(t, err, "migration 20220302_bad: missing Up section")
+}
+
+func TestMigrationOrder(t *testing.T) {
+	require.Panics(t, func() { RegisterMigration("", func(o Ormer) error { return nil }, nil) })
+	require.Panics(t, func() { RegisterMigration("20220101_no_up", nil, nil) })
+
+	RegisterMigrationSQL("test_20220102_b", "-- +migrate Up\nSELECT 1;\n")
+	RegisterMigration("test_20220101_a", func(o Ormer) error { return nil }, nil)
+	require.Panics(t, func() { RegisterMigrationSQL("test_20220101_a", "-- +migrate Up\nSELECT 1;\n") }, "duplicate")
+	defer func() {
+		migrationCache.Lock()
+		delete(migrationCache.cache, "test_20220101_a")
+		delete(migrationCache.cache, "test_20220102_b")
+		migrationCache.Unlock()
+	}()
+
+	var names []string
+	for _, m := range getSortedMigrations() {
+		names = append(names, m.name)
+	}
+	require.Equal(t, []string{"test_20220101_a", "test_20220102_b"}, names)
+	require.Nil(t, getMigration("test_20220101_a").down)
+
+	applied := map[string]*appliedMigration{"1": {name: "1"}, "3": {name: "3"}, "2": {name: "2"}}
+	require.Equal(t, []string{"3", "2"}, lastApplied(applied, 2))
+	require.Equal(t, []string{"3", "2", "1"}, lastApplied(applied, 5))
+
+	// 1 is merged from another branch and applied after 3
+	now := time.Now()
+	applied = map[string]*appliedMigration{
+		"1": {name: "1", appliedAt: now.Add(time.Hour)},
+		"2": {name: "2", appliedAt: now},
+		"3": {name: "3", appliedAt: now},
+	}
+	require.Equal(t, []string{"1", "3"}, lastApplied(applied, 2))
+}
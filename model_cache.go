@@ -1,6 +1,7 @@
 package orm
 
 import (
+	"reflect"
 	"sync"
 )
 
@@ -13,6 +14,10 @@ var (
 	modelCache = &_modelCache{
 		cache: make(map[string]*modelInfo),
 	}
+
+	rawModelCache = &_rawModelCache{
+		cache: make(map[reflect.Type]*modelInfo),
+	}
 )
 
 // model info collection
@@ -41,6 +46,33 @@ func (mc *_modelCache) clean() {
 	mc.done = false
 }
 
+// raw model info collection.
+// used to scan raw query rows into structs which are not registered.
+type _rawModelCache struct {
+	sync.RWMutex
+	cache map[reflect.Type]*modelInfo
+}
+
+// get model info by struct type, build and cache it if not found.
+// the struct is not required to have a pk field or a table.
+func (rc *_rawModelCache) get(typ reflect.Type) *modelInfo {
+	rc.RLock()
+	mi, ok := rc.cache[typ]
+	rc.RUnlock()
+	if ok {
+		return mi
+	}
+
+	rc.Lock()
+	defer rc.Unlock()
+	if mi, ok = rc.cache[typ]; ok {
+		return mi
+	}
+	mi = newModelInfo(reflect.New(typ))
+	rc.cache[typ] = mi
+	return mi
+}
+
 // ResetModelCache Clean model cache. Then you can re-RegisterModel.
 // Common use this api for test case.
 func ResetModelCache() {
@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/std0d9k81/dynamic"
+	"github.com/std0d9k81/orm/sqlbuilder"
 )
 
 var nullContainer string
@@ -21,6 +22,19 @@ type modelInfo struct {
 	fields    *fields
 	sharded   bool
 	addrField reflect.Value //store the original struct value
+
+	// the strategy compute table suffix from shard field, nil if sharded by TableSuffix method
+	shardField    *fieldInfo
+	shardStrategy ShardStrategy
+
+	// the indexes of index and unique tags in order of fields, followed by TableUnique and TableIndex
+	indexes []*indexInfo
+}
+
+// index of model
+type indexInfo struct {
+	unique bool
+	fields []*fieldInfo
 }
 
 // new model info
@@ -32,9 +46,68 @@ func newModelInfo(val reflect.Value) (mi *modelInfo) {
 	mi.name = ind.Type().Name()
 	mi.fullName = getFullName(ind.Type())
 	mi.addFields(ind, "", []int{})
+	mi.addIndexes(val)
 	return
 }
 
+// add the indexes of index and unique tags, TableUnique and TableIndex methods
+func (mi *modelInfo) addIndexes(val reflect.Value) {
+	for _, fi := range mi.fields.fieldsDB {
+		if fi.pk {
+			continue
+		}
+		if fi.unique {
+			mi.indexes = append(mi.indexes, &indexInfo{unique: true, fields: []*fieldInfo{fi}})
+		} else if fi.index {
+			mi.indexes = append(mi.indexes, &indexInfo{fields: []*fieldInfo{fi}})
+		}
+	}
+
+	for _, unique := range []bool{true, false} {
+		method := "TableIndex"
+		if unique {
+			method = "TableUnique"
+		}
+		for _, names := range getTableIndexes(val, method) {
+			if len(names) == 0 {
+				panic(fmt.Errorf("register model: empty index of %s for model `%s`", method, mi.fullName))
+			}
+			index := &indexInfo{unique: unique}
+			for _, name := range names {
+				fi, ok := mi.fields.GetByAny(name)
+				if !ok {
+					panic(fmt.Errorf("register model: wrong field `%s` of %s for model `%s`", name, method, mi.fullName))
+				}
+				index.fields = append(index.fields, fi)
+			}
+			mi.indexes = append(mi.indexes, index)
+		}
+	}
+}
+
+// getIndexName return the name of index, e.g. idx_order_user_id_created and uniq_user_email
+func (mi *modelInfo) getIndexName(index *indexInfo) string {
+	return index.getName(mi.table)
+}
+
+// getName return the name of index on table
+func (index *indexInfo) getName(table string) string {
+	prefix := "idx"
+	if index.unique {
+		prefix = "uniq"
+	}
+	return fmt.Sprintf("%s_%s_%s", prefix, table, strings.Join(index.columns(), "_"))
+}
+
+// columns return the columns of index
+func (index *indexInfo) columns() []string {
+	columns := make([]string, len(index.fields))
+	for i, fi := range index.fields {
+		columns[i] = fi.column
+	}
+	return columns
+}
+
 // set auto auto field
 func (mi *modelInfo) setAutoField(ind reflect.Value, id int64) {
 	if mi.fields.auto != nil {
@@ -55,11 +128,14 @@ func (mi *modelInfo) getExistPk(ind reflect.Value) (column string, value interfa
 	return fi.column, v.Interface(), v.IsValid()
 }
 
-func (mi *modelInfo) getTableByInd(ind reflect.Value) string {
+func (mi *modelInfo) getTableByInd(ind reflect.Value) (string, error) {
 	var tableName string
 
 	if mi.sharded {
-		tableSuffix := getTableSuffix(ind.Addr())
+		tableSuffix, err := mi.getTableSuffix(ind)
+		if err != nil {
+			return "", err
+		}
 		if tableSuffix == "" {
 			panic(ErrNoTableSuffix(mi.table))
 		}
@@ -67,7 +143,41 @@ func (mi *modelInfo) getTableByInd(ind reflect.Value) string {
 	} else {
 		tableName = mi.table
 	}
-	return tableName
+	return tableName, nil
+}
+
+// getTableSuffix return the table suffix of model value, computed by the shard strategy or TableSuffix method.
+func (mi *modelInfo) getTableSuffix(ind reflect.Value) (string, error) {
+	if mi.shardStrategy != nil {
+		return mi.getShardSuffix(ind.FieldByIndex(mi.shardField.fieldIndex).Interface())
+	}
+	if ind.CanAddr() {
+		ind = ind.Addr()
+	}
+	return getTableSuffix(ind), nil
+}
+
+// groupBySuffix group the models of slice by table suffix, the suffixes are in order of first appearance.
+func (mi *modelInfo) groupBySuffix(sind reflect.Value) ([]string, map[string]reflect.Value, error) {
+	var (
+		suffixes []string
+		groups   = make(map[string]reflect.Value)
+		typ      = reflect.SliceOf(sind.Type().Elem())
+	)
+	for i := 0; i < sind.Len(); i++ {
+		elem := sind.Index(i)
+		suffix, err := mi.getTableSuffix(reflect.Indirect(elem))
+		if err != nil {
+			return nil, nil, err
+		}
+		group, ok := groups[suffix]
+		if !ok {
+			suffixes = append(suffixes, suffix)
+			group = reflect.MakeSlice(typ, 0, 1)
+		}
+		groups[suffix] = reflect.Append(group, elem)
+	}
+	return suffixes, groups, nil
 }
 
 func (mi *modelInfo) getTableBySuffix(suffix string) string {
@@ -141,6 +251,46 @@ func (mi *modelInfo) addFields(ind reflect.Value, mName string, index []int) {
 	}
 }
 
+// getInsertColumns return the columns written by inserting ind, the readonly columns are omitted,
+// so are the columns with default tag whose values are NULL, i.e. nil pointer or invalid sql.Null*,
+// then the database fill them with the default. the zero value of other fields is written as is.
+func (mi *modelInfo) getInsertColumns(ind reflect.Value) []string {
+	columns := make([]string, 0, len(mi.fields.writecols))
+	for _, fi := range mi.fields.fieldsDB {
+		if fi.readonly {
+			continue
+		}
+		if fi.hasInitial && isNullValue(ind.FieldByIndex(fi.fieldIndex)) {
+			continue
+		}
+		columns = append(columns, fi.column)
+	}
+	return columns
+}
+
+// checkWritable panic if any of columns is readonly
+func (mi *modelInfo) checkWritable(columns []string) {
+	for _, column := range columns {
+		if fi := mi.fields.GetByColumn(column); fi != nil && fi.readonly {
+			panic(fmt.Errorf("readonly field `%s` for model `%s` cannot be written", fi.name, mi.fullName))
+		}
+	}
+}
+
+// validate check the values of columns in ind against the size, digits and decimals tags of fields
+func (mi *modelInfo) validate(ind reflect.Value, columns []string) error {
+	for _, column := range columns {
+		fi := mi.fields.GetByColumn(column)
+		if fi == nil || (fi.size == 0 && fi.digits == 0) {
+			continue
+		}
+		if err := fi.validate(ind.FieldByIndex(fi.fieldIndex)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (mi *modelInfo) getFieldInfo(anyName string) *fieldInfo {
 	fi, ok := mi.fields.GetByAny(anyName)
 	if !ok {
@@ -212,6 +362,22 @@ func (mi *modelInfo) getValueContainers(ind reflect.Value, columns []string, ign
 	return dynColumns, containers
 }
 
+// getScanContainers return the containers of selected columns and annotations.
+// the annotation is scanned to the field named alias if exists, otherwise it's discarded.
+func (mi *modelInfo) getScanContainers(ind reflect.Value, selectNames []string, annotations []annotation) ([]string, []interface{}) {
+	dynColumns, containers := mi.getValueContainers(ind, selectNames, false)
+	for _, a := range annotations {
+		if _, ok := mi.fields.GetByAny(a.alias); !ok {
+			containers = append(containers, new(interface{}))
+			continue
+		}
+		dyn, container := mi.getValueContainers(ind, []string{a.alias}, false)
+		dynColumns = append(dynColumns, dyn...)
+		containers = append(containers, container...)
+	}
+	return dynColumns, containers
+}
+
 func (mi *modelInfo) setDynamicFields(ind reflect.Value, dynColumns []string) error {
 	if len(dynColumns) == 0 {
 		return nil
@@ -273,8 +439,10 @@ func (mi *modelInfo) parseExprs(exprs []string) (fi *fieldInfo, operator string,
 	return
 }
 
-// getOrderByCols builds the order by cols
-func (mi *modelInfo) getOrderByCols(orders []string) []string {
+// getOrderByCols builds the order by cols.
+// json fields can be ordered by path, e.g. "-Content__json__user__age", and annotations by alias.
+// nolint:gocyclo
+func (mi *modelInfo) getOrderByCols(orders []string, annotations []annotation, cond *sqlbuilder.Cond) []string {
 	if len(orders) == 0 {
 		return nil
 	}
@@ -290,6 +458,11 @@ func (mi *modelInfo) getOrderByCols(orders []string) []string {
 			order = order[1:]
 		}
 
+		if isAnnotation(annotations, order) {
+			cols = append(cols, fmt.Sprintf("%s %s", quote(order), direction))
+			continue
+		}
+
 		exprs := strings.Split(order, ExprSep)
 
 		fi, _, ok := mi.parseExprs(exprs)
@@ -297,6 +470,11 @@ func (mi *modelInfo) getOrderByCols(orders []string) []string {
 			panic(fmt.Errorf("unknown field/column name `%s`", strings.Join(exprs, ExprSep)))
 		}
 
+		if len(exprs) > 2 && exprs[1] == "json" {
+			cols = append(cols, fmt.Sprintf("%s %s", getJSONExtractSQL(fi, exprs[2:], cond), direction))
+			continue
+		}
+
 		cols = append(cols, fmt.Sprintf("%s %s", quote(fi.column), direction))
 	}
 
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"strings"
 
 	"github.com/std0d9k81/kate/log/ctxzap"
 	"github.com/std0d9k81/orm/sqlbuilder"
@@ -19,6 +20,8 @@ const (
 	HintRouterMaster = `{"router":"m"} `
 )
 
+// PrepareInsert prepare the statement inserting the columns except readonly ones.
+// the columns with default tag are always written, as the columns of statement are fixed.
 func (mi *modelInfo) PrepareInsert(ctx context.Context, db dbQueryer, tableSuffix string) (StmtQueryer, string, error) {
 	logger := ctxzap.Extract(ctx).With(defaultLoggerTag)
 	if mi.sharded && tableSuffix == "" {
@@ -28,13 +31,13 @@ func (mi *modelInfo) PrepareInsert(ctx context.Context, db dbQueryer, tableSuffi
 	table := mi.getTableBySuffix(tableSuffix)
 	builder := sqlbuilder.NewInsertBuilder()
 
-	values := make([]interface{}, len(mi.fields.dbcols))
+	values := make([]interface{}, len(mi.fields.writecols))
 	for i := 0; i < len(values); i++ {
 		values[i] = nil
 	}
 
 	builder.InsertInto(quote(table)).
-		Cols(quoteAll(mi.fields.dbcols)...).
+		Cols(quoteAll(mi.fields.writecols)...).
 		Values(values...)
 
 	query, _ := builder.Build()
@@ -48,7 +51,10 @@ func (mi *modelInfo) PrepareInsert(ctx context.Context, db dbQueryer, tableSuffi
 }
 
 func (mi *modelInfo) InsertStmt(ctx context.Context, stmt StmtQueryer, ind reflect.Value) (int64, error) {
-	values := mi.getValues(ind, mi.fields.dbcols)
+	if err := mi.validate(ind, mi.fields.writecols); err != nil {
+		return 0, err
+	}
+	values := mi.getValues(ind, mi.fields.writecols)
 	result, err := stmt.ExecContext(ctx, values...)
 	if err != nil {
 		return 0, err
@@ -61,10 +67,14 @@ func (mi *modelInfo) Read(ctx context.Context, db dbQueryer, ind reflect.Value,
 	var (
 		whereColumns []string
 		whereValues  []interface{}
-		table        = mi.getTableByInd(ind)
 		logger       = ctxzap.Extract(ctx).With(defaultLoggerTag)
 	)
 
+	table, err := mi.getTableByInd(ind)
+	if err != nil {
+		return err
+	}
+
 	if len(whereNames) > 0 {
 		whereColumns = mi.getColumns(whereNames)
 		whereValues = mi.getValues(ind, whereNames)
@@ -88,23 +98,14 @@ func (mi *modelInfo) Read(ctx context.Context, db dbQueryer, ind reflect.Value,
 		builder.ForUpdate()
 	}
 
-	var (
-		query string
-		args  []interface{}
-	)
-
-	if forceMaster {
-		query, args = sqlbuilder.Build(HintRouterMaster, builder).Build()
-	} else {
-		query, args = builder.Build()
-	}
+	query, args := buildQuery(builder, forceMaster)
 
 	if DebugSQLBuilder {
 		logger.Debug("sqlbuilder:read", zap.String("query", query), zap.Any("args", args))
 	}
 
 	dynColumns, containers := mi.getValueContainers(ind, mi.fields.dbcols, false)
-	err := db.QueryRowContext(ctx, query, args...).Scan(containers...)
+	err = db.QueryRowContext(ctx, query, args...).Scan(containers...)
 	switch {
 	case err == sql.ErrNoRows:
 		return ErrNoRows
@@ -118,15 +119,23 @@ func (mi *modelInfo) Read(ctx context.Context, db dbQueryer, ind reflect.Value,
 	return nil
 }
 
+// Insert insert the row of ind, the columns are computed by getInsertColumns
 func (mi *modelInfo) Insert(ctx context.Context, db dbQueryer, ind reflect.Value) (int64, error) {
 	var (
-		table   = mi.getTableByInd(ind)
-		values  = mi.getValues(ind, mi.fields.dbcols)
+		columns = mi.getInsertColumns(ind)
 		builder = sqlbuilder.NewInsertBuilder()
 		logger  = ctxzap.Extract(ctx).With(defaultLoggerTag)
 	)
 
-	builder.InsertInto(quote(table)).Cols(quoteAll(mi.fields.dbcols)...).Values(values...)
+	table, err := mi.getTableByInd(ind)
+	if err != nil {
+		return 0, err
+	}
+	if err = mi.validate(ind, columns); err != nil {
+		return 0, err
+	}
+
+	builder.InsertInto(quote(table)).Cols(quoteAll(columns)...).Values(mi.getValues(ind, columns)...)
 
 	query, args := builder.Build()
 
@@ -153,29 +162,37 @@ func (mi *modelInfo) Update(ctx context.Context, db dbQueryer, ind reflect.Value
 		logger     = ctxzap.Extract(ctx).With(defaultLoggerTag)
 	)
 
-	// if specify setNames length is zero, then commit all columns.
+	// if specify setNames length is zero, then commit all columns except readonly ones.
 	if len(setNames) == 0 {
-		setColumns = make([]string, 0, len(mi.fields.dbcols)-1)
+		setColumns = make([]string, 0, len(mi.fields.writecols))
 		for _, fi := range mi.fields.fieldsDB {
-			if !fi.pk {
+			if !fi.pk && !fi.readonly {
 				setColumns = append(setColumns, fi.column)
 			}
 		}
 	} else {
 		setColumns = mi.getColumns(setNames)
+		mi.checkWritable(setColumns)
 	}
 
 	if len(setColumns) == 0 {
 		panic(errors.New("no columns to update"))
 	}
 
+	if err := mi.validate(ind, setColumns); err != nil {
+		return 0, err
+	}
+
 	setValues := mi.getValues(ind, setColumns)
 
-	table := mi.getTableByInd(ind)
+	table, err := mi.getTableByInd(ind)
+	if err != nil {
+		return 0, err
+	}
 	builder := sqlbuilder.NewUpdateBuilder()
 
 	builder.Update(quote(table)).
-		Set(getAssignments(builder, quoteAll(setColumns), setValues)...).
+		Set(getAssignments(mi, builder, setColumns, setValues)...).
 		Where(builder.E(quote(pkName), pkValue))
 
 	query, args := builder.Build()
@@ -195,10 +212,14 @@ func (mi *modelInfo) Delete(ctx context.Context, db dbQueryer, ind reflect.Value
 	var (
 		whereColumns []string
 		whereValues  []interface{}
-		table        = mi.getTableByInd(ind)
 		logger       = ctxzap.Extract(ctx).With(defaultLoggerTag)
 	)
 
+	table, err := mi.getTableByInd(ind)
+	if err != nil {
+		return 0, err
+	}
+
 	// if specify whereNames length > 0, then use it for where condition.
 	if len(whereNames) > 0 {
 		whereColumns = mi.getColumns(whereNames)
@@ -234,6 +255,8 @@ func (mi *modelInfo) Delete(ctx context.Context, db dbQueryer, ind reflect.Value
 	return result.RowsAffected()
 }
 
+// InsertMulti insert the rows of sind in bulks, the columns of rows are computed by getInsertColumns.
+// the rows in a statement must have the same columns, so the bulk is split where the columns change.
 func (mi *modelInfo) InsertMulti(
 	ctx context.Context,
 	db dbQueryer,
@@ -244,8 +267,11 @@ func (mi *modelInfo) InsertMulti(
 	var (
 		table   = mi.getTableBySuffix(tableSuffix)
 		builder *sqlbuilder.InsertBuilder
+		columns []string
+		rows    int
 		length  = sind.Len()
 		count   int64
+		bulkIdx int
 		logger  = ctxzap.Extract(ctx).With(defaultLoggerTag)
 	)
 
@@ -253,34 +279,53 @@ func (mi *modelInfo) InsertMulti(
 		return 0, nil
 	}
 
-	bulkIdx := 0
-	for i := 1; i <= length; i++ {
-		if builder == nil {
-			builder = sqlbuilder.NewInsertBuilder().InsertInto(quote(table)).Cols(quoteAll(mi.fields.dbcols)...)
+	// validate all rows before inserting any of them
+	inds := make([]reflect.Value, length)
+	for i := range inds {
+		inds[i] = reflect.Indirect(sind.Index(i))
+		if err := mi.validate(inds[i], mi.fields.writecols); err != nil {
+			return 0, err
 		}
+	}
 
-		ind := reflect.Indirect(sind.Index(i - 1))
-		values := mi.getValues(ind, mi.fields.dbcols)
-		builder.Values(values...)
+	flush := func() error {
+		bulkIdx++
+		query, args := builder.Build()
 
-		if i%bulk == 0 || i == length {
-			bulkIdx++
+		if DebugSQLBuilder {
+			logger.Debug("sqlbuilder:insert_multi",
+				zap.String("query", query),
+				zap.Any("args", args),
+				zap.Int("bulk_idx", bulkIdx))
+		}
 
-			query, args := builder.Build()
+		if _, err := db.ExecContext(ctx, query, args...); err != nil {
+			return err
+		}
+		count += int64(rows)
+		builder, rows = nil, 0
+		return nil
+	}
 
-			if DebugSQLBuilder {
-				logger.Debug("sqlbuilder:insert_multi",
-					zap.String("query", query),
-					zap.Any("args", args),
-					zap.Int("bulk_idx", bulkIdx))
+	for i, ind := range inds {
+		rowColumns := mi.getInsertColumns(ind)
+		if builder != nil && !equalStrings(columns, rowColumns) {
+			if err := flush(); err != nil {
+				return count, err
 			}
+		}
 
-			_, err := db.ExecContext(ctx, query, args...)
-			if err != nil {
+		if builder == nil {
+			columns = rowColumns
+			builder = sqlbuilder.NewInsertBuilder().InsertInto(quote(table)).Cols(quoteAll(columns)...)
+		}
+		builder.Values(mi.getValues(ind, columns)...)
+		rows++
+
+		if rows == bulk || i == length-1 {
+			if err := flush(); err != nil {
 				return count, err
 			}
-			count += int64(i % bulk)
-			builder = nil
 		}
 	}
 
@@ -290,22 +335,18 @@ func (mi *modelInfo) InsertMulti(
 func (mi *modelInfo) UpdateBatch(ctx context.Context, db dbQueryer,
 	qs *querySetter, cond *Condition, params Params) (int64, error) {
 	var (
-		setNames  = make([]string, 0, len(params))
-		setValues = make([]interface{}, 0, len(params))
-		logger    = ctxzap.Extract(ctx).With(defaultLoggerTag)
+		setColumns, setValues = mi.getParamsColumns(params)
+		logger                = ctxzap.Extract(ctx).With(defaultLoggerTag)
 	)
 
-	for name, value := range params {
-		setNames = append(setNames, name)
-		setValues = append(setValues, value)
+	table, err := qs.getTable()
+	if err != nil {
+		return 0, err
 	}
-	setColumns := mi.getColumns(setNames)
-
-	table := mi.getTableBySuffix(qs.tableSuffix)
 	builder := sqlbuilder.NewUpdateBuilder()
 
 	builder.Update(quote(table)).
-		Set(getAssignments(builder, quoteAll(setColumns), setValues)...)
+		Set(getAssignments(mi, builder, setColumns, setValues)...)
 
 	if cond != nil && !cond.IsEmpty() {
 		builder.Where(cond.GetWhereSQL(mi, &builder.Cond))
@@ -324,13 +365,56 @@ func (mi *modelInfo) UpdateBatch(ctx context.Context, db dbQueryer,
 	return result.RowsAffected()
 }
 
+// UpdateParams update the row of pk in ind with params
+func (mi *modelInfo) UpdateParams(ctx context.Context, db dbQueryer, ind reflect.Value, params Params) (int64, error) {
+	pkName, pkValue, ok := mi.getExistPk(ind)
+	if !ok {
+		return 0, ErrMissPK
+	}
+
+	if len(params) == 0 {
+		panic(errors.New("no columns to update"))
+	}
+
+	var (
+		setColumns, setValues = mi.getParamsColumns(params)
+		builder               = sqlbuilder.NewUpdateBuilder()
+		logger                = ctxzap.Extract(ctx).With(defaultLoggerTag)
+	)
+
+	table, err := mi.getTableByInd(ind)
+	if err != nil {
+		return 0, err
+	}
+
+	builder.Update(quote(table)).
+		Set(getAssignments(mi, builder, setColumns, setValues)...).
+		Where(builder.E(quote(pkName), pkValue))
+
+	query, args := builder.Build()
+
+	if DebugSQLBuilder {
+		logger.Debug("sqlbuilder:update_params", zap.String("query", query), zap.Any("args", args))
+	}
+
+	result, err := db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 func (mi *modelInfo) DeleteBatch(ctx context.Context, db dbQueryer, qs *querySetter, cond *Condition) (int64, error) {
 	var (
-		table   = mi.getTableBySuffix(qs.tableSuffix)
 		builder = sqlbuilder.NewDeleteBuilder()
 		logger  = ctxzap.Extract(ctx).With(defaultLoggerTag)
 	)
 
+	table, err := qs.getTable()
+	if err != nil {
+		return 0, err
+	}
+
 	builder.DeleteFrom(quote(table))
 
 	if cond != nil && !cond.IsEmpty() {
@@ -350,8 +434,9 @@ func (mi *modelInfo) DeleteBatch(ctx context.Context, db dbQueryer, qs *querySet
 	return result.RowsAffected()
 }
 
+// getSelectBuilder builds the select builder of query setter
 // nolint:gocyclo,lll
-func (mi *modelInfo) getQueryArgsForRead(qs *querySetter, cond *Condition, selectNames []string) (string, []interface{}) {
+func (mi *modelInfo) getSelectBuilder(qs *querySetter, cond *Condition, selectNames []string) (*sqlbuilder.SelectBuilder, error) {
 	var selectColumns []string
 	if len(selectNames) > 0 {
 		selectColumns = mi.getColumns(selectNames)
@@ -359,21 +444,28 @@ func (mi *modelInfo) getQueryArgsForRead(qs *querySetter, cond *Condition, selec
 		selectColumns = mi.fields.dbcols
 	}
 
-	builder := sqlbuilder.NewSelectBuilder()
-	table := mi.getTableBySuffix(qs.tableSuffix)
+	table, err := qs.getTable()
+	if err != nil {
+		return nil, err
+	}
 
+	builder := sqlbuilder.NewSelectBuilder()
 	if qs.distinct {
 		builder.Distinct()
 	}
 
 	builder.Select(quoteAll(selectColumns)...).From(quote(table))
 
+	for _, a := range qs.annotations {
+		builder.SelectVarAs(a.expr.build(mi, builder.Args.Flavor), quote(a.alias))
+	}
+
 	if cond != nil && !cond.IsEmpty() {
 		builder.Where(cond.GetWhereSQL(mi, &builder.Cond))
 	}
 
 	if len(qs.orders) > 0 {
-		builder.OrderBy(mi.getOrderByCols(qs.orders)...)
+		builder.OrderBy(mi.getOrderByCols(qs.orders, qs.annotations, &builder.Cond)...)
 	}
 
 	if len(qs.groups) > 0 {
@@ -392,16 +484,153 @@ func (mi *modelInfo) getQueryArgsForRead(qs *querySetter, cond *Condition, selec
 		builder.ForUpdate()
 	}
 
-	var (
-		query string
-		args  []interface{}
-	)
-	if qs.forceMaster {
-		query, args = sqlbuilder.Build(HintRouterMaster, builder).Build()
+	return builder, nil
+}
+
+// nolint:lll
+func (mi *modelInfo) getQueryArgsForRead(qs *querySetter, cond *Condition, selectNames []string) (string, []interface{}, error) {
+	builder, err := mi.getSelectBuilder(qs, cond, selectNames)
+	if err != nil {
+		return "", nil, err
+	}
+	query, args := buildQuery(builder, qs.forceMaster)
+	return query, args, nil
+}
+
+// getCountBuilder builds the count builder of query setter.
+// the query is wrapped as a subquery if DISTINCT, GROUP BY, LIMIT or OFFSET is used.
+func (mi *modelInfo) getCountBuilder(qs *querySetter, cond *Condition) (*sqlbuilder.SelectBuilder, error) {
+	builder := sqlbuilder.NewSelectBuilder()
+	builder.Select("COUNT(1)")
+
+	if !qs.distinct && len(qs.groups) == 0 && qs.limit <= 0 && qs.offset <= 0 {
+		table, err := qs.getTable()
+		if err != nil {
+			return nil, err
+		}
+		builder.From(quote(table))
+		if cond != nil && !cond.IsEmpty() {
+			builder.Where(cond.GetWhereSQL(mi, &builder.Cond))
+		}
+		return builder, nil
+	}
+
+	innerQs := *qs
+	innerQs.orders = nil
+	innerQs.annotations = nil
+	innerQs.forUpdate = false
+
+	// only the group columns are needed to count the groups
+	var selectNames []string
+	if !qs.distinct && len(qs.groups) > 0 {
+		selectNames = qs.groups
+	}
+
+	inner, err := mi.getSelectBuilder(&innerQs, cond, selectNames)
+	if err != nil {
+		return nil, err
+	}
+	builder.From(builder.BuilderAs(inner, "t"))
+	return builder, nil
+}
+
+// getExistBuilder builds the exist builder of query setter, only one row is selected.
+func (mi *modelInfo) getExistBuilder(qs *querySetter, cond *Condition) (*sqlbuilder.SelectBuilder, error) {
+	builder := sqlbuilder.NewSelectBuilder()
+	builder.Select("1")
+
+	if qs.limit <= 0 && qs.offset <= 0 {
+		// DISTINCT and GROUP BY don't change whether there are rows or not
+		table, err := qs.getTable()
+		if err != nil {
+			return nil, err
+		}
+		builder.From(quote(table))
+		if cond != nil && !cond.IsEmpty() {
+			builder.Where(cond.GetWhereSQL(mi, &builder.Cond))
+		}
 	} else {
-		query, args = builder.Build()
+		innerQs := *qs
+		innerQs.orders = nil
+		innerQs.annotations = nil
+		innerQs.forUpdate = false
+		inner, err := mi.getSelectBuilder(&innerQs, cond, []string{mi.fields.pk.column})
+		if err != nil {
+			return nil, err
+		}
+		builder.From(builder.BuilderAs(inner, "t"))
+	}
+
+	return builder.Limit(1), nil
+}
+
+// getCountDistinctBuilder builds the builder to count the distinct values of columns
+func (mi *modelInfo) getCountDistinctBuilder(qs *querySetter, cond *Condition, names []string) (*sqlbuilder.SelectBuilder, error) {
+	if len(names) == 0 {
+		panic(fmt.Errorf("<QuerySeter.CountDistinct> need at least one column"))
+	}
+
+	builder := sqlbuilder.NewSelectBuilder()
+
+	if len(qs.groups) == 0 && qs.limit <= 0 && qs.offset <= 0 {
+		table, err := qs.getTable()
+		if err != nil {
+			return nil, err
+		}
+		columns := strings.Join(quoteAll(mi.getColumns(names)), ", ")
+		builder.Select(fmt.Sprintf("COUNT(DISTINCT %s)", columns)).
+			From(quote(table))
+		if cond != nil && !cond.IsEmpty() {
+			builder.Where(cond.GetWhereSQL(mi, &builder.Cond))
+		}
+		return builder, nil
+	}
+
+	innerQs := *qs
+	innerQs.orders = nil
+	innerQs.annotations = nil
+	innerQs.forUpdate = false
+	innerQs.distinct = true
+
+	inner, err := mi.getSelectBuilder(&innerQs, cond, names)
+	if err != nil {
+		return nil, err
 	}
-	return query, args
+	builder.Select("COUNT(1)").From(builder.BuilderAs(inner, "t"))
+	return builder, nil
+}
+
+// buildQuery return the query and args of builder, with the hint of force master if needed
+func buildQuery(builder sqlbuilder.Builder, forceMaster bool) (string, []interface{}) {
+	if forceMaster {
+		return sqlbuilder.Build(HintRouterMaster+"$?", builder).Build()
+	}
+	return builder.Build()
+}
+
+// nolint:lll
+func (mi *modelInfo) ReadRows(ctx context.Context, db dbQueryer, qs *querySetter, cond *Condition, selectNames []string) (*modelRows, error) {
+	logger := ctxzap.Extract(ctx).With(defaultLoggerTag)
+
+	if len(selectNames) == 0 {
+		selectNames = mi.fields.dbcols
+	}
+
+	query, args, err := mi.getQueryArgsForRead(qs, cond, selectNames)
+	if err != nil {
+		return nil, err
+	}
+
+	if DebugSQLBuilder {
+		logger.Debug("sqlbuilder:read_rows", zap.String("query", query), zap.Any("args", args))
+	}
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return newModelRows(mi, rows, selectNames, qs.annotations), nil
 }
 
 // nolint:lll
@@ -418,7 +647,10 @@ func (mi *modelInfo) ReadOne(ctx context.Context, db dbQueryer, qs *querySetter,
 		selectNames = mi.fields.dbcols
 	}
 
-	query, args := mi.getQueryArgsForRead(qs, cond, selectNames)
+	query, args, err := mi.getQueryArgsForRead(qs, cond, selectNames)
+	if err != nil {
+		return err
+	}
 
 	if DebugSQLBuilder {
 		logger.Debug("sqlbuilder:read_one", zap.String("query", query), zap.Any("args", args))
@@ -436,7 +668,7 @@ func (mi *modelInfo) ReadOne(ctx context.Context, db dbQueryer, qs *querySetter,
 		elem := reflect.New(mi.addrField.Elem().Type())
 		elemInd := reflect.Indirect(elem)
 
-		dynColumns, containers := mi.getValueContainers(elemInd, selectNames, false)
+		dynColumns, containers := mi.getScanContainers(elemInd, selectNames, qs.annotations)
 		if err = rows.Scan(containers...); err != nil {
 			return err
 		}
@@ -495,7 +727,10 @@ func (mi *modelInfo) ReadBatch(ctx context.Context, db dbQueryer, qs *querySette
 		selectNames = mi.fields.dbcols
 	}
 
-	query, args := mi.getQueryArgsForRead(qs, cond, selectNames)
+	query, args, err := mi.getQueryArgsForRead(qs, cond, selectNames)
+	if err != nil {
+		return err
+	}
 
 	if DebugSQLBuilder {
 		logger.Debug("sqlbuilder:read_batch", zap.String("query", query), zap.Any("args", args))
@@ -513,7 +748,7 @@ func (mi *modelInfo) ReadBatch(ctx context.Context, db dbQueryer, qs *querySette
 		elem := reflect.New(mi.addrField.Elem().Type())
 		elemInd := reflect.Indirect(elem)
 
-		dynColumns, containers := mi.getValueContainers(elemInd, selectNames, false)
+		dynColumns, containers := mi.getScanContainers(elemInd, selectNames, qs.annotations)
 		if err = rows.Scan(containers...); err != nil {
 			return err
 		}
@@ -541,24 +776,61 @@ func (mi *modelInfo) ReadBatch(ctx context.Context, db dbQueryer, qs *querySette
 // nolint:lll
 func (mi *modelInfo) Count(ctx context.Context, db dbQueryer, qs *querySetter, cond *Condition) (count int64, err error) {
 	logger := ctxzap.Extract(ctx).With(defaultLoggerTag)
-	table := mi.getTableBySuffix(qs.tableSuffix)
-	builder := sqlbuilder.NewSelectBuilder()
-	builder.Select("COUNT(1)").From(quote(table))
+	builder, err := mi.getCountBuilder(qs, cond)
+	if err != nil {
+		return 0, err
+	}
+	query, args := buildQuery(builder, qs.forceMaster)
 
-	if cond != nil && !cond.IsEmpty() {
-		builder.Where(cond.GetWhereSQL(mi, &builder.Cond))
+	if DebugSQLBuilder {
+		logger.Debug("sqlbuilder:count", zap.String("query", query), zap.Any("args", args))
 	}
 
-	query, args := builder.Build()
+	err = db.QueryRowContext(ctx, query, args...).Scan(&count)
+	return
+}
+
+// nolint:lll
+func (mi *modelInfo) CountDistinct(ctx context.Context, db dbQueryer, qs *querySetter, cond *Condition, names []string) (count int64, err error) {
+	logger := ctxzap.Extract(ctx).With(defaultLoggerTag)
+	builder, err := mi.getCountDistinctBuilder(qs, cond, names)
+	if err != nil {
+		return 0, err
+	}
+	query, args := buildQuery(builder, qs.forceMaster)
 
 	if DebugSQLBuilder {
-		logger.Debug("sqlbuilder:count", zap.String("query", query), zap.Any("args", args))
+		logger.Debug("sqlbuilder:count_distinct", zap.String("query", query), zap.Any("args", args))
 	}
 
 	err = db.QueryRowContext(ctx, query, args...).Scan(&count)
 	return
 }
 
+// nolint:lll
+func (mi *modelInfo) Exist(ctx context.Context, db dbQueryer, qs *querySetter, cond *Condition) (bool, error) {
+	logger := ctxzap.Extract(ctx).With(defaultLoggerTag)
+	builder, err := mi.getExistBuilder(qs, cond)
+	if err != nil {
+		return false, err
+	}
+	query, args := buildQuery(builder, qs.forceMaster)
+
+	if DebugSQLBuilder {
+		logger.Debug("sqlbuilder:exist", zap.String("query", query), zap.Any("args", args))
+	}
+
+	var one int
+	err = db.QueryRowContext(ctx, query, args...).Scan(&one)
+	switch {
+	case err == sql.ErrNoRows:
+		return false, nil
+	case err != nil:
+		return false, err
+	}
+	return true, nil
+}
+
 // getEqualWhereExprs return where exprs used in sqlbuilder.Cond
 func getEqualWhereExprs(cond *sqlbuilder.Cond, columns []string, values []interface{}) []string {
 	whereExprs := make([]string, len(columns))
@@ -568,24 +840,53 @@ func getEqualWhereExprs(cond *sqlbuilder.Cond, columns []string, values []interf
 	return whereExprs
 }
 
-// getAssignments return set exprs used in sqlbuilder.UpdateBuilder
-func getAssignments(ub *sqlbuilder.UpdateBuilder, columns []string, values []interface{}) []string {
+// getParamsColumns return the columns and values of params, the json mutators are checked with the json fields
+func (mi *modelInfo) getParamsColumns(params Params) ([]string, []interface{}) {
+	var (
+		columns = make([]string, 0, len(params))
+		values  = make([]interface{}, 0, len(params))
+	)
+
+	for name, value := range params {
+		fi, ok := mi.fields.GetByAny(name)
+		if !ok {
+			panic(fmt.Errorf("wrong db field/column name `%s` for model `%s`", name, mi.fullName))
+		}
+		if _, ok = value.(*jsonMutation); ok {
+			checkJSONField(fi)
+		}
+		if fi.readonly {
+			panic(fmt.Errorf("readonly field `%s` for model `%s` cannot be written", fi.name, mi.fullName))
+		}
+		columns = append(columns, fi.column)
+		values = append(values, value)
+	}
+	return columns, values
+}
+
+// getAssignments return set exprs used in sqlbuilder.UpdateBuilder, the columns are quoted in the flavor of builder
+func getAssignments(mi *modelInfo, ub *sqlbuilder.UpdateBuilder, columns []string, values []interface{}) []string {
 	assignments := make([]string, len(columns))
 	for i := range columns {
+		column := quoteFlavor(ub.Args.Flavor, columns[i])
 		switch v := values[i].(type) {
 		case *colValue:
 			switch v.op {
 			case ColAdd:
-				assignments[i] = ub.Add(columns[i], v.value)
+				assignments[i] = ub.Add(column, v.value)
 			case ColSub:
-				assignments[i] = ub.Sub(columns[i], v.value)
+				assignments[i] = ub.Sub(column, v.value)
 			case ColMul:
-				assignments[i] = ub.Mul(columns[i], v.value)
+				assignments[i] = ub.Mul(column, v.value)
 			case ColDiv:
-				assignments[i] = ub.Div(columns[i], v.value)
+				assignments[i] = ub.Div(column, v.value)
 			}
+		case *FExpr:
+			assignments[i] = ub.Assign(column, v.build(mi, ub.Args.Flavor))
+		case *jsonMutation:
+			assignments[i] = ub.Assign(column, v.build(columns[i], ub.Args.Flavor))
 		default:
-			assignments[i] = ub.Assign(columns[i], v)
+			assignments[i] = ub.Assign(column, v)
 		}
 	}
 	return assignments
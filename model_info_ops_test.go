@@ -0,0 +1,118 @@
+package orm
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/std0d9k81/orm/sqlbuilder"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCountQuery(t *testing.T) {
+	mi := newModelInfo(reflect.ValueOf(&Person{}))
+	mi.table = "person"
+	cond := NewCondition().And("Name", "zhang")
+
+	qs := &querySetter{mi: mi}
+	query, args := buildQuery(mustBuilder(mi.getCountBuilder(qs, cond)), false)
+	require.Equal(t, "SELECT COUNT(1) FROM `person` WHERE `name` = ?", query)
+	require.Equal(t, []interface{}{"zhang"}, args)
+
+	qs = &querySetter{mi: mi, groups: []string{"Name"}, orders: []string{"-ID"}}
+	query, _ = buildQuery(mustBuilder(mi.getCountBuilder(qs, cond)), false)
+	require.Equal(t, "SELECT COUNT(1) FROM (SELECT `name` FROM `person` WHERE `name` = ? GROUP BY `name`) AS t", query)
+
+	qs = &querySetter{mi: mi, distinct: true, limit: 10, offset: 5}
+	query, _ = buildQuery(mustBuilder(mi.getCountBuilder(qs, cond)), true)
+	require.Equal(t, HintRouterMaster+"SELECT COUNT(1) FROM (SELECT DISTINCT `id`, `name` FROM `person` WHERE `name` = ? LIMIT 10 OFFSET 5) AS t", query)
+
+	qs = &querySetter{mi: mi}
+	query, _ = buildQuery(mustBuilder(mi.getCountDistinctBuilder(qs, cond, []string{"Name"})), false)
+	require.Equal(t, "SELECT COUNT(DISTINCT `name`) FROM `person` WHERE `name` = ?", query)
+
+	qs = &querySetter{mi: mi, limit: 10}
+	query, _ = buildQuery(mustBuilder(mi.getCountDistinctBuilder(qs, nil, []string{"Name"})), false)
+	require.Equal(t, "SELECT COUNT(1) FROM (SELECT DISTINCT `name` FROM `person` LIMIT 10) AS t", query)
+}
+
+func TestReadKeepsLimit(t *testing.T) {
+	mi := newModelInfo(reflect.ValueOf(&shardedOrder{}))
+	mi.table = "order"
+	mi.sharded = true
+	mi.shardField = mi.getFieldInfo("UserID")
+	mi.shardStrategy = HashMod(4)
+
+	// the reads fail in routing, the limit of them must not be left in the QuerySetter counted later
+	qs := &querySetter{mi: mi, cond: NewCondition().And("UserID__in", 1, 2)}
+	require.Error(t, qs.All(&[]shardedOrder{}))
+	require.Equal(t, 0, qs.limit)
+	require.Error(t, qs.One(&shardedOrder{}))
+	require.Equal(t, 0, qs.limit)
+
+	qs.cond = NewCondition().And("UserID", 1)
+	query, _ := buildQuery(mustBuilder(mi.getCountBuilder(qs, qs.cond)), false)
+	require.Equal(t, "SELECT COUNT(1) FROM `order_1` WHERE `user_id` = ?", query)
+}
+
+func TestExistQuery(t *testing.T) {
+	mi := newModelInfo(reflect.ValueOf(&Person{}))
+	mi.table = "person"
+	cond := NewCondition().And("Name", "zhang")
+
+	qs := &querySetter{mi: mi, groups: []string{"Name"}}
+	query, _ := buildQuery(mustBuilder(mi.getExistBuilder(qs, cond)), false)
+	require.Equal(t, "SELECT 1 FROM `person` WHERE `name` = ? LIMIT 1", query)
+
+	qs = &querySetter{mi: mi, offset: 10}
+	query, _ = buildQuery(mustBuilder(mi.getExistBuilder(qs, cond)), false)
+	require.Equal(t, "SELECT 1 FROM (SELECT `id` FROM `person` WHERE `name` = ? LIMIT 18446744073709551615 OFFSET 10) AS t LIMIT 1", query)
+}
+
+func TestUpdateAssignments(t *testing.T) {
+	mi := newModelInfo(reflect.ValueOf(&Person{}))
+	mi.table = "person"
+
+	builder := sqlbuilder.NewUpdateBuilder()
+	builder.Update(quote(mi.table)).
+		Set(getAssignments(mi, builder, []string{"id", "name"}, []interface{}{ColValue(ColAdd, 1), F("ID").Sub(F("Name")).Div(2)})...).
+		Where(builder.E(quote("name"), "zhang"))
+	query, args := builder.Build()
+	require.Equal(t, "UPDATE `person` SET `id` = `id` + ?, `name` = ((`id` - `name`) / ?) WHERE `name` = ?", query)
+	require.Equal(t, []interface{}{int64(1), 2, "zhang"}, args)
+}
+
+func TestAnnotateQuery(t *testing.T) {
+	mi := newModelInfo(reflect.ValueOf(&Person{}))
+	mi.table = "person"
+	search := Match("Name").Against("zhang")
+	cond := NewCondition().And("__search", search)
+
+	qs := &querySetter{mi: mi, orders: []string{"-score", "ID"}, limit: 10}
+	qs = qs.Annotate("score", search).Annotate("double_id", F("ID").Mul(2)).(*querySetter)
+	query, args := buildQuery(mustBuilder(mi.getSelectBuilder(qs, cond, nil)), false)
+	require.Equal(t, "SELECT `id`, `name`, MATCH(`name`) AGAINST(? IN NATURAL LANGUAGE MODE) AS `score`, (`id` * ?) AS `double_id` "+
+		"FROM `person` WHERE MATCH(`name`) AGAINST(? IN NATURAL LANGUAGE MODE) ORDER BY `score` DESC, `id` ASC LIMIT 10", query)
+	require.Equal(t, []interface{}{"zhang", 2, "zhang"}, args)
+
+	query, _ = buildQuery(mustBuilder(mi.getCountBuilder(qs, cond)), false)
+	require.Equal(t, "SELECT COUNT(1) FROM (SELECT `id`, `name` FROM `person` WHERE MATCH(`name`) AGAINST(? IN NATURAL LANGUAGE MODE) LIMIT 10) AS t", query)
+
+	builder := sqlbuilder.PostgreSQL.NewSelectBuilder()
+	builder.Select("*").SelectVarAs(search.build(mi, sqlbuilder.PostgreSQL), "score").From("person")
+	query, _ = builder.Build()
+	require.Equal(t, `SELECT *, ts_rank(to_tsvector("name"), plainto_tsquery($1)) AS score FROM person`, query)
+
+	person := &Person{}
+	dynColumns, containers := mi.getScanContainers(reflect.ValueOf(person).Elem(), []string{"id"}, qs.annotations)
+	require.Empty(t, dynColumns)
+	require.Len(t, containers, 3)
+	require.Equal(t, &person.ID, containers[0])
+}
+
+// mustBuilder return the builder, it panics if the builder failed
+func mustBuilder(builder *sqlbuilder.SelectBuilder, err error) *sqlbuilder.SelectBuilder {
+	if err != nil {
+		panic(err)
+	}
+	return builder
+}
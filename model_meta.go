@@ -0,0 +1,111 @@
+package orm
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/std0d9k81/orm/sqlbuilder"
+)
+
+// ModelMeta is the metadata of registered model
+type ModelMeta struct {
+	Name     string
+	FullName string
+	DB       string
+	Table    string
+	Sharded  bool
+	Fields   []*FieldMeta
+	Indexes  []*IndexMeta
+}
+
+// FieldMeta is the metadata of model field parsed from the orm tags
+type FieldMeta struct {
+	Name   string
+	Column string
+	// Type is the column type in the flavor of database, from the type tag or derived from the go type
+	Type     string
+	Size     int
+	Digits   int
+	Decimals int
+	// Default is the value of default tag, valid if HasDefault
+	Default    string
+	HasDefault bool
+	Null       bool
+	PK         bool
+	Auto       bool
+	JSON       bool
+	ReadOnly   bool
+}
+
+// IndexMeta is the metadata of model index
+type IndexMeta struct {
+	Name    string
+	Unique  bool
+	Columns []string
+}
+
+// GetModelMeta return the metadata of registered model, md is the model struct or pointer to it.
+// for example:
+//	meta := orm.GetModelMeta(&User{})
+//	for _, field := range meta.Fields {
+//		fmt.Println(field.Column, field.Type, field.ReadOnly)
+//	}
+func GetModelMeta(md interface{}) *ModelMeta {
+	typ := reflect.Indirect(reflect.ValueOf(md)).Type()
+	mi, ok := modelCache.get(getFullName(typ))
+	if !ok {
+		panic(fmt.Errorf("<GetModelMeta> model `%s` not registered", getFullName(typ)))
+	}
+	return mi.getMeta()
+}
+
+// getMeta return the metadata of model
+func (mi *modelInfo) getMeta() *ModelMeta {
+	flavor := mi.getFlavor()
+	meta := &ModelMeta{
+		Name:     mi.name,
+		FullName: mi.fullName,
+		DB:       mi.db,
+		Table:    mi.table,
+		Sharded:  mi.sharded,
+		Fields:   make([]*FieldMeta, len(mi.fields.fieldsDB)),
+		Indexes:  make([]*IndexMeta, len(mi.indexes)),
+	}
+
+	for i, fi := range mi.fields.fieldsDB {
+		meta.Fields[i] = &FieldMeta{
+			Name:       fi.name,
+			Column:     fi.column,
+			Type:       fi.getMetaType(flavor),
+			Size:       fi.size,
+			Digits:     fi.digits,
+			Decimals:   fi.decimals,
+			Default:    fi.initial,
+			HasDefault: fi.hasInitial,
+			Null:       fi.isNullable(),
+			PK:         fi.pk,
+			Auto:       fi.auto,
+			JSON:       fi.json,
+			ReadOnly:   fi.readonly,
+		}
+	}
+
+	for i, index := range mi.indexes {
+		meta.Indexes[i] = &IndexMeta{
+			Name:    mi.getIndexName(index),
+			Unique:  index.unique,
+			Columns: index.columns(),
+		}
+	}
+	return meta
+}
+
+// getMetaType return the column type of field, empty if the go type is unsupported and no type tag
+func (fi *fieldInfo) getMetaType(flavor sqlbuilder.Flavor) (typ string) {
+	defer func() {
+		if recover() != nil {
+			typ = ""
+		}
+	}()
+	return fi.getColumnType(flavor)
+}
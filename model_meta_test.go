@@ -0,0 +1,113 @@
+package orm
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestModelMeta(t *testing.T) {
+	mi := newModelInfo(reflect.ValueOf(&decimalModel{}))
+	mi.table = "decimal_model"
+
+	meta := mi.getMeta()
+	require.Equal(t, "decimalModel", meta.Name)
+	require.Equal(t, "decimal_model", meta.Table)
+	require.Len(t, meta.Fields, 6)
+	require.Equal(t, &FieldMeta{Name: "ID", Column: "id", Type: "bigint", PK: true, Auto: true}, meta.Fields[0])
+	require.Equal(t, &FieldMeta{Name: "Price", Column: "price", Type: "decimal(10,2)", Digits: 10, Decimals: 2}, meta.Fields[1])
+	require.Equal(t, &FieldMeta{Name: "Note", Column: "note", Type: "varchar(4)", Size: 4,
+		Default: "none", HasDefault: true, Null: true}, meta.Fields[4])
+	require.Equal(t, &FieldMeta{Name: "Updated", Column: "updated", Type: "datetime",
+		Default: "CURRENT_TIMESTAMP", HasDefault: true, ReadOnly: true}, meta.Fields[5])
+
+	mi = newModelInfo(reflect.ValueOf(&indexModel{}))
+	mi.table = "index_model"
+	require.Equal(t, &IndexMeta{Name: "uniq_index_model_code_user_id", Unique: true, Columns: []string{"code", "user_id"}},
+		mi.getMeta().Indexes[1])
+
+	meta = GetModelMeta(shardedPerson{})
+	require.Equal(t, "person", meta.Table)
+	require.Equal(t, "default", meta.DB)
+	require.Panics(t, func() { GetModelMeta(&decimalModel{}) }, "not registered")
+}
+
+func TestFieldTags(t *testing.T) {
+	for _, field := range []reflect.StructField{
+		{Name: "Age", Type: reflect.TypeOf(0), Tag: `orm:"size(10)"`},
+		{Name: "Age", Type: reflect.TypeOf(0), Tag: `orm:"digits(10);decimals(2)"`},
+		{Name: "Age", Type: reflect.TypeOf(0), Tag: `orm:"auto;default(1)"`},
+		{Name: "Age", Type: reflect.TypeOf(0), Tag: `orm:"pk;readonly"`},
+	} {
+		typ := reflect.StructOf([]reflect.StructField{{Name: "ID", Type: reflect.TypeOf(0), Tag: `orm:"column(id)"`}, field})
+		require.Panics(t, func() { newModelInfo(reflect.New(typ)) }, string(field.Tag))
+	}
+
+	typ := reflect.StructOf([]reflect.StructField{
+		{Name: "ID", Type: reflect.TypeOf(0), Tag: `orm:"pk"`},
+		{Name: "Name", Type: reflect.TypeOf(sql.NullString{}), Tag: `orm:"size(8)"`},
+		{Name: "Rate", Type: reflect.TypeOf(sql.NullFloat64{}), Tag: `orm:"digits(3);decimals(1)"`},
+	})
+	require.NotPanics(t, func() { newModelInfo(reflect.New(typ)) })
+}
+
+func TestInsertColumns(t *testing.T) {
+	mi := newModelInfo(reflect.ValueOf(&decimalModel{}))
+	require.Equal(t, []string{"id", "price", "amount", "status", "note"}, mi.fields.writecols)
+
+	m := &decimalModel{Updated: time.Now()}
+	require.Equal(t, []string{"id", "price", "amount", "status"}, mi.getInsertColumns(reflect.ValueOf(m).Elem()),
+		"nil note is omitted, zero status is written")
+
+	note := ""
+	m = &decimalModel{Note: &note}
+	require.Equal(t, []string{"id", "price", "amount", "status", "note"}, mi.getInsertColumns(reflect.ValueOf(m).Elem()))
+
+	ddl := newModelInfo(reflect.ValueOf(&ddlModel{}))
+	columns := ddl.getInsertColumns(reflect.ValueOf(ddlModel{}))
+	require.Contains(t, columns, "enabled", "false is inserted though default(true)")
+	require.Contains(t, columns, "age")
+
+	typ := reflect.StructOf([]reflect.StructField{
+		{Name: "ID", Type: reflect.TypeOf(0), Tag: `orm:"column(id);pk"`},
+		{Name: "Name", Type: reflect.TypeOf(sql.NullString{}), Tag: `orm:"default(none)"`},
+	})
+	mi = newModelInfo(reflect.New(typ))
+	require.Equal(t, []string{"id"}, mi.getInsertColumns(reflect.New(typ).Elem()), "invalid sql.NullString is omitted")
+	row := reflect.New(typ).Elem()
+	row.Field(1).Set(reflect.ValueOf(sql.NullString{Valid: true}))
+	require.Equal(t, []string{"id", "name"}, mi.getInsertColumns(row))
+
+	mi = newModelInfo(reflect.ValueOf(&decimalModel{}))
+	require.Panics(t, func() { mi.checkWritable([]string{"price", "updated"}) })
+	require.Panics(t, func() { mi.getParamsColumns(Params{"Updated": time.Now()}) })
+}
+
+func TestValidateField(t *testing.T) {
+	mi := newModelInfo(reflect.ValueOf(&decimalModel{}))
+	validate := func(m *decimalModel) error {
+		return mi.validate(reflect.ValueOf(m).Elem(), mi.fields.writecols)
+	}
+
+	amount, note := "-999.9994", "good"
+	require.NoError(t, validate(&decimalModel{Price: 99999999.994, Amount: &amount, Note: &note}))
+
+	note = "toolong"
+	err := validate(&decimalModel{Note: &note})
+	require.IsType(t, &FieldError{}, err)
+	require.Contains(t, err.Error(), "decimalModel.Note is longer than size 4")
+
+	note = "长度四个"
+	require.NoError(t, validate(&decimalModel{Note: &note}), "size counts characters")
+
+	err = validate(&decimalModel{Price: 99999999.995})
+	require.EqualError(t, err, "<Ormer> field github.com/std0d9k81/orm.decimalModel.Price 9.9999999995e+07 is out of range of decimal(10,2)")
+
+	amount = "1000"
+	require.Error(t, validate(&decimalModel{Amount: &amount}))
+	amount = "abc"
+	require.Error(t, validate(&decimalModel{Amount: &amount}))
+}
@@ -9,7 +9,7 @@ import (
 // register models.
 // PrefixOrSuffix means table name prefix or suffix.
 // isPrefix whether the prefix is prefix or suffix
-func registerModel(PrefixOrSuffix, dbName string, model interface{}, isPrefix bool) {
+func registerModel(PrefixOrSuffix, dbName string, model interface{}, isPrefix bool) *modelInfo {
 	val := reflect.ValueOf(model)
 	typ := reflect.Indirect(val).Type()
 
@@ -50,6 +50,7 @@ func registerModel(PrefixOrSuffix, dbName string, model interface{}, isPrefix bo
 	mi.sharded = isSharded(val)
 
 	modelCache.add(mi)
+	return mi
 }
 
 // boostrap models
@@ -61,6 +62,18 @@ func bootStrap() {
 	if dbCache.getDefault() == nil {
 		panic(fmt.Errorf("must have one register DataBase alias named `default`"))
 	}
+
+	for _, mi := range modelCache.cache {
+		strategy, ok := mi.shardStrategy.(DBShardStrategy)
+		if !ok {
+			continue
+		}
+		for _, suffix := range strategy.Suffixes() {
+			if _, ok := dbCache.get(strategy.DB(suffix)); !ok {
+				panic(fmt.Errorf("model `%s`: db `%s` of shard `%s` not registered", mi.fullName, strategy.DB(suffix), suffix))
+			}
+		}
+	}
 }
 
 // RegisterModel register models
@@ -93,14 +106,45 @@ func RegisterModelWithSuffix(suffix, db string, models ...interface{}) {
 	}
 }
 
+// RegisterShardedModel register model sharded by the strategy on field, the table suffix is computed from the field value.
+// for example:
+//	orm.RegisterShardedModel("default", new(Order), "UserID", orm.HashMod(16))
+//	// the order of user 35 is in table `order_3`
+//	db.Insert(&Order{UserID: 35})
+//	db.QueryTable(new(Order)).Filter("UserID", 35).All(&orders)
+func RegisterShardedModel(db string, model interface{}, field string, strategy ShardStrategy) {
+	if modelCache.done {
+		panic(fmt.Errorf("RegisterShardedModel must be run before BootStrap"))
+	}
+	if strategy == nil {
+		panic(fmt.Errorf("RegisterShardedModel strategy cannot nil"))
+	}
+
+	mi := registerModel("", db, model, false)
+	fi, ok := mi.fields.GetByAny(field)
+	if !ok {
+		panic(fmt.Errorf("register model: wrong shard field `%s` for model `%s`", field, mi.fullName))
+	}
+	mi.sharded = true
+	mi.shardField = fi
+	mi.shardStrategy = strategy
+}
+
 // BootStrap bootrap models.
-// make all model parsed and can not add more models
+// make all model parsed and can not add more models.
+// if StrictBootStrap is true, the schema of databases is checked by CheckSchema, and it panics on drift.
 func BootStrap() {
 	if modelCache.done {
 		return
 	}
-	modelCache.Lock()
-	defer modelCache.Unlock()
-	bootStrap()
-	modelCache.done = true
+	func() {
+		modelCache.Lock()
+		defer modelCache.Unlock()
+		bootStrap()
+		modelCache.done = true
+	}()
+
+	if StrictBootStrap {
+		checkSchemaOnBoot()
+	}
 }
@@ -16,11 +16,20 @@ const (
 // 1 is attr
 // 2 is tag
 var supportTag = map[string]int{
-	"-":      TagTypeNoArgs,
-	"pk":     TagTypeNoArgs,
-	"auto":   TagTypeNoArgs,
-	"json":   TagTypeOptionalArgs,
-	"column": TagTypeWithArgs,
+	"-":        TagTypeNoArgs,
+	"pk":       TagTypeNoArgs,
+	"auto":     TagTypeNoArgs,
+	"json":     TagTypeOptionalArgs,
+	"column":   TagTypeWithArgs,
+	"size":     TagTypeWithArgs,
+	"digits":   TagTypeWithArgs,
+	"decimals": TagTypeWithArgs,
+	"type":     TagTypeWithArgs,
+	"null":     TagTypeNoArgs,
+	"default":  TagTypeWithArgs,
+	"readonly": TagTypeNoArgs,
+	"index":    TagTypeNoArgs,
+	"unique":   TagTypeNoArgs,
 }
 
 // get reflect.Type name with package path.
@@ -59,6 +68,21 @@ func getTableSuffix(val reflect.Value) string {
 	return ""
 }
 
+// getTableIndexes get the composite indexes of struct.
+// If the struct implement the TableIndex or TableUnique method, then get the result as the fields of indexes,
+// e.g. [][]string{{"UserID", "Created"}, {"Status"}}
+func getTableIndexes(val reflect.Value, method string) [][]string {
+	if fun := val.MethodByName(method); fun.IsValid() {
+		vals := fun.Call([]reflect.Value{})
+		if len(vals) > 0 {
+			if indexes, ok := vals[0].Interface().([][]string); ok {
+				return indexes
+			}
+		}
+	}
+	return nil
+}
+
 // get snaked column name
 func getColumnName(sf reflect.StructField, col string) string {
 	column := col
@@ -86,7 +110,7 @@ func parseStructTag(mi *modelInfo, data string) (attrs map[string]bool, tags map
 		switch {
 		case i < 0:
 			tag = v
-		case i > 0 && strings.Index(v, ")") == (len(v)-1):
+		case i > 0 && strings.LastIndex(v, ")") == (len(v)-1):
 			tag = v[:i]
 			args = v[i+1 : len(v)-1]
 		}
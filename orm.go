@@ -39,12 +39,16 @@ type Ormer interface {
 	//  user := new(User)
 	//  id, err = Ormer.Insert(user)
 	//  user must a pointer and Insert will set user's pk field
+	// the readonly fields and the NULL fields with default tag, i.e. nil pointer or invalid sql.Null*, are not inserted,
+	// the database fill them.
 	Insert(interface{}) (int64, error)
-	// insert some models to database
+	// insert some models to database, bulk is the max rows of one INSERT statement.
+	// the models of sharded table are split by table suffix, and the count of all shards is returned.
 	InsertMulti(bulk int, mds interface{}) (int64, error)
 	// update model to database.
 	// cols set the columns those want to update.
 	// find model by Id(pk) field and update columns specified by fields, if cols is null then update all columns
+	// except the readonly ones.
 	Update(md interface{}, cols ...string) (int64, error)
 	// delete model in database
 	Delete(md interface{}, cols ...string) (int64, error)
@@ -76,6 +80,17 @@ type Ormer interface {
 
 var _ Ormer = new(orm)
 
+// ParamsUpdater is implemented by the Ormer of NewOrm, it's separated from Ormer to keep the implementations of Ormer working.
+type ParamsUpdater interface {
+	// update model to database with params.
+	// find model by Id(pk) field and update columns in params, the values can be ColValue, F and the json mutators.
+	// for example:
+	//	num, err = o.(orm.ParamsUpdater).UpdateParams(user, orm.JSONSet("Profile", "$.address.city", "Beijing"))
+	UpdateParams(md interface{}, params Params) (int64, error)
+}
+
+var _ ParamsUpdater = new(orm)
+
 type orm struct {
 	ctx    context.Context
 	db     dbQueryer
@@ -105,7 +120,11 @@ func (o *orm) ReadFromMaster(md interface{}, cols ...string) error {
 	if !o.isTx && o.db == nil {
 		o.Using(mi.db)
 	}
-	return mi.Read(o.ctx, o.db, ind, cols, false, true)
+	db, err := o.routeModel(mi, ind)
+	if err != nil {
+		return err
+	}
+	return mi.Read(o.ctx, db, ind, cols, false, true)
 }
 
 // read data to model
@@ -114,7 +133,11 @@ func (o *orm) Read(md interface{}, cols ...string) error {
 	if !o.isTx && o.db == nil {
 		o.Using(mi.db)
 	}
-	return mi.Read(o.ctx, o.db, ind, cols, false, false)
+	db, err := o.routeModel(mi, ind)
+	if err != nil {
+		return err
+	}
+	return mi.Read(o.ctx, db, ind, cols, false, false)
 }
 
 // read data to model, like Read(), but use "SELECT FOR UPDATE" form
@@ -123,7 +146,11 @@ func (o *orm) ReadForUpdate(md interface{}, cols ...string) error {
 	if !o.isTx && o.db == nil {
 		o.Using(mi.db)
 	}
-	return mi.Read(o.ctx, o.db, ind, cols, true, false)
+	db, err := o.routeModel(mi, ind)
+	if err != nil {
+		return err
+	}
+	return mi.Read(o.ctx, db, ind, cols, true, false)
 }
 
 // insert model data to database
@@ -132,7 +159,11 @@ func (o *orm) Insert(md interface{}) (int64, error) {
 	if !o.isTx && o.db == nil {
 		o.Using(mi.db)
 	}
-	id, err := mi.Insert(o.ctx, o.db, ind)
+	db, err := o.routeModel(mi, ind)
+	if err != nil {
+		return 0, err
+	}
+	id, err := mi.Insert(o.ctx, db, ind)
 	if err != nil {
 		return id, err
 	}
@@ -142,7 +173,9 @@ func (o *orm) Insert(md interface{}) (int64, error) {
 	return id, nil
 }
 
-// insert some models to database
+// insert some models to database.
+// the models of sharded table are grouped by table suffix, and the shards are inserted concurrently like AllShards.
+// the errors of shards are returned together as ShardErrors, with the count of rows inserted.
 func (o *orm) InsertMulti(bulk int, mds interface{}) (int64, error) {
 	sind := reflect.Indirect(reflect.ValueOf(mds))
 
@@ -155,18 +188,33 @@ func (o *orm) InsertMulti(bulk int, mds interface{}) (int64, error) {
 		panic(errors.New("<Ormer> InsertMulti args must be array or slice"))
 	}
 
-	tableSuffix := getTableSuffix(sind.Index(0))
-	for i := 1; i < sind.Len(); i++ {
-		if tableSuffix != getTableSuffix(sind.Index(i)) {
-			return 0, ErrTableSuffixNotSameInBatchInsert
-		}
-	}
-
 	mi, _ := o.getMiInd(sind.Index(0).Interface(), false)
 	if !o.isTx && o.db == nil {
 		o.Using(mi.db)
 	}
-	return mi.InsertMulti(o.ctx, o.db, sind, bulk, tableSuffix)
+
+	suffixes, groups, err := mi.groupBySuffix(sind)
+	if err != nil {
+		return 0, err
+	}
+	if len(suffixes) == 1 {
+		db, err := o.routeDB(mi, suffixes[0])
+		if err != nil {
+			return 0, err
+		}
+		return mi.InsertMulti(o.ctx, db, sind, bulk, suffixes[0])
+	}
+
+	counts := make([]int64, len(suffixes))
+	err = o.runShards(suffixes, func(i int, suffix string) error {
+		db, err := o.routeDB(mi, suffix)
+		if err != nil {
+			return err
+		}
+		counts[i], err = mi.InsertMulti(o.ctx, db, groups[suffix], bulk, suffix)
+		return err
+	})
+	return sumInt64(counts), err
 }
 
 // update model to database.
@@ -176,7 +224,24 @@ func (o *orm) Update(md interface{}, cols ...string) (int64, error) {
 	if !o.isTx && o.db == nil {
 		o.Using(mi.db)
 	}
-	return mi.Update(o.ctx, o.db, ind, cols)
+	db, err := o.routeModel(mi, ind)
+	if err != nil {
+		return 0, err
+	}
+	return mi.Update(o.ctx, db, ind, cols)
+}
+
+// update model to database with params.
+func (o *orm) UpdateParams(md interface{}, params Params) (int64, error) {
+	mi, ind := o.getMiInd(md, true)
+	if !o.isTx && o.db == nil {
+		o.Using(mi.db)
+	}
+	db, err := o.routeModel(mi, ind)
+	if err != nil {
+		return 0, err
+	}
+	return mi.UpdateParams(o.ctx, db, ind, params)
 }
 
 // delete model in database
@@ -186,7 +251,11 @@ func (o *orm) Delete(md interface{}, cols ...string) (int64, error) {
 	if !o.isTx && o.db == nil {
 		o.Using(mi.db)
 	}
-	return mi.Delete(o.ctx, o.db, ind, cols)
+	db, err := o.routeModel(mi, ind)
+	if err != nil {
+		return 0, err
+	}
+	return mi.Delete(o.ctx, db, ind, cols)
 }
 
 func (o *orm) QueryTable(ptrStruct interface{}) QuerySetter {
@@ -209,6 +278,36 @@ func (o *orm) QueryTable(ptrStruct interface{}) QuerySetter {
 	return qs
 }
 
+// routeModel return the db queryer of the table of model value, the error of computing table suffix is returned
+func (o *orm) routeModel(mi *modelInfo, ind reflect.Value) (dbQueryer, error) {
+	tableSuffix, err := mi.getTableSuffix(ind)
+	if err != nil {
+		return nil, err
+	}
+	return o.routeDB(mi, tableSuffix)
+}
+
+// routeDB return the db queryer of the table with suffix, the table is routed to database by DBShardStrategy.
+// the current database is used if not routed, and ErrCrossDBTransaction is returned if routed to another database in transaction.
+func (o *orm) routeDB(mi *modelInfo, tableSuffix string) (dbQueryer, error) {
+	dbName := mi.getShardDB(tableSuffix)
+	if dbName == "" || dbName == o.dbName {
+		return o.db, nil
+	}
+	if o.isTx {
+		return nil, ErrCrossDBTransaction
+	}
+
+	db, ok := dbCache.get(dbName)
+	if !ok {
+		panic(fmt.Errorf("db not registered: %v", dbName))
+	}
+	if Debug {
+		return newDbQueryLog(o.ctx, dbName, db.DB), nil
+	}
+	return db.DB, nil
+}
+
 func (o *orm) Using(dbName string) {
 	if o.isTx {
 		panic(fmt.Errorf("<Ormer.Using> transaction has been start, cannot change db"))
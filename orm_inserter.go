@@ -59,7 +59,11 @@ func newPreparedInserter(orm *orm, mi *modelInfo, tableSuffix string) (Inserter,
 	pi := new(preparedInserter)
 	pi.orm = orm
 	pi.mi = mi
-	st, query, err := mi.PrepareInsert(orm.ctx, orm.db, tableSuffix)
+	db, err := orm.routeDB(mi, tableSuffix)
+	if err != nil {
+		return nil, err
+	}
+	st, query, err := mi.PrepareInsert(orm.ctx, db, tableSuffix)
 	if err != nil {
 		return nil, err
 	}
@@ -27,7 +27,20 @@ type RawQueryer interface {
 	// QueryRow query data and map to container
 	QueryRow(containers ...interface{}) error
 
-	// QueryRows query data rows and map to container
+	// QueryRows query data rows and map to container.
+	// container is a ptr of empty struct slice, the struct is not required to be registered,
+	// columns are mapped by the `orm` tag column(...) or the snake name of fields.
+	// for example:
+	//	type userStat struct {
+	//		UserName string
+	//		Total    int64 `orm:"column(cnt)"`
+	//	}
+	//	var stats []*userStat
+	//	err := Ormer.Raw("SELECT user_name, COUNT(1) AS cnt FROM ...").QueryRows(&stats)
+	// container can also be a ptr of:
+	//	*[]int64, *[]string ...                    one column results
+	//	*[]map[string]interface{}                  column name to value of every row
+	//	*map[int64]string, *map[string]int ...     two columns results, key and value
 	QueryRows(container interface{}) error
 
 	// SetArgs set args
@@ -126,34 +139,40 @@ func (rq *rawQueryer) QueryRow(containers ...interface{}) error {
 }
 
 // QueryRows query data rows and map to container
-// nolint:gocyclo
 func (rq *rawQueryer) QueryRows(container interface{}) error {
-	var (
-		val = reflect.ValueOf(container)
-		ind = reflect.Indirect(val)
+	val := reflect.ValueOf(container)
+	ind := reflect.Indirect(val)
 
-		isPtr    = true
-		fullName string
-	)
+	if val.Kind() != reflect.Ptr || (ind.Kind() != reflect.Slice && ind.Kind() != reflect.Map) || ind.Len() != 0 {
+		panic(fmt.Errorf("<RawQueryer> QueryRows() container should be a ptr of empty slice or map"))
+	}
 
-	if val.Kind() != reflect.Ptr || ind.Kind() != reflect.Slice || ind.Len() != 0 {
-		panic(fmt.Errorf("<RawQueryer> QueryRows() container should be a ptr of empty struct slice"))
+	if ind.Kind() == reflect.Map {
+		return rq.queryRowsToMap(ind)
 	}
 
 	typ := ind.Type().Elem()
-	switch typ.Kind() {
-	case reflect.Ptr:
-		fullName = getFullName(typ.Elem())
-	case reflect.Struct:
-		isPtr = false
-		fullName = getFullName(typ)
+	switch {
+	case typ.Kind() == reflect.Ptr && typ.Elem().Kind() == reflect.Struct:
+		return rq.queryRowsToStructs(ind, typ.Elem(), true)
+	case typ.Kind() == reflect.Struct && typ != timeType:
+		return rq.queryRowsToStructs(ind, typ, false)
+	case typ.Kind() == reflect.Map:
+		if typ.Key().Kind() != reflect.String {
+			panic(fmt.Errorf("<RawQueryer> QueryRows() map element should have string keys, but got `%v`", typ))
+		}
+		return rq.queryRowsToMaps(ind)
 	default:
-		panic(fmt.Errorf("<RawQueryer> QueryRows() container should be a ptr of empty struct slice"))
+		return rq.queryRowsToValues(ind)
 	}
+}
 
-	mi, ok := modelCache.get(fullName)
+// queryRowsToStructs scan every row to a struct, the struct is not required to be registered
+func (rq *rawQueryer) queryRowsToStructs(ind reflect.Value, elemTyp reflect.Type, isPtr bool) error {
+	// registered models are preferred, other structs are mapped by their `orm` tags or snake names.
+	mi, ok := modelCache.get(getFullName(elemTyp))
 	if !ok {
-		panic(fmt.Errorf("<RawQueryer> model `%v` not registered", fullName))
+		mi = rawModelCache.get(elemTyp)
 	}
 
 	rows, err := rq.orm.db.QueryContext(rq.ctx, rq.query, rq.args...)
@@ -204,6 +223,145 @@ func (rq *rawQueryer) QueryRows(container interface{}) error {
 	return nil
 }
 
+// queryRowsToValues scan the only column of every row to a slice of primitive values
+func (rq *rawQueryer) queryRowsToValues(ind reflect.Value) error {
+	rows, err := rq.orm.db.QueryContext(rq.ctx, rq.query, rq.args...)
+	if err != nil {
+		return err
+	}
+	// nolint:errcheck
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	if len(columns) != 1 {
+		return fmt.Errorf("<RawQueryer> QueryRows() into `%v` need 1 column, but got %d", ind.Type(), len(columns))
+	}
+
+	slice := reflect.New(ind.Type()).Elem()
+	for rows.Next() {
+		var value interface{}
+		if err = rows.Scan(&value); err != nil {
+			return err
+		}
+
+		elem := reflect.New(ind.Type().Elem()).Elem()
+		if err = setRawValue(elem, value); err != nil {
+			return err
+		}
+		slice = reflect.Append(slice, elem)
+	}
+
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	ind.Set(slice)
+
+	return nil
+}
+
+// queryRowsToMaps scan every row to a map of column name to value
+func (rq *rawQueryer) queryRowsToMaps(ind reflect.Value) error {
+	rows, err := rq.orm.db.QueryContext(rq.ctx, rq.query, rq.args...)
+	if err != nil {
+		return err
+	}
+	// nolint:errcheck
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	var (
+		mapTyp     = ind.Type().Elem()
+		slice      = reflect.New(ind.Type()).Elem()
+		values     = make([]interface{}, len(columns))
+		containers = make([]interface{}, len(columns))
+	)
+
+	for i := range values {
+		containers[i] = &values[i]
+	}
+
+	for rows.Next() {
+		if err = rows.Scan(containers...); err != nil {
+			return err
+		}
+
+		m := reflect.MakeMapWithSize(mapTyp, len(columns))
+		for i, column := range columns {
+			elem := reflect.New(mapTyp.Elem()).Elem()
+			if err = setRawValue(elem, values[i]); err != nil {
+				return fmt.Errorf("column `%s`: %v", column, err)
+			}
+			m.SetMapIndex(reflect.ValueOf(column).Convert(mapTyp.Key()), elem)
+		}
+		slice = reflect.Append(slice, m)
+	}
+
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	ind.Set(slice)
+
+	return nil
+}
+
+// queryRowsToMap scan two columns results to a map, the first column is key, the second is value
+func (rq *rawQueryer) queryRowsToMap(ind reflect.Value) error {
+	rows, err := rq.orm.db.QueryContext(rq.ctx, rq.query, rq.args...)
+	if err != nil {
+		return err
+	}
+	// nolint:errcheck
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	if len(columns) != 2 {
+		return fmt.Errorf("<RawQueryer> QueryRows() into `%v` need 2 columns, but got %d", ind.Type(), len(columns))
+	}
+
+	var (
+		mapTyp        = ind.Type()
+		m             = reflect.MakeMap(mapTyp)
+		key, value    interface{}
+		keyInd, elInd reflect.Value
+	)
+
+	for rows.Next() {
+		if err = rows.Scan(&key, &value); err != nil {
+			return err
+		}
+
+		keyInd = reflect.New(mapTyp.Key()).Elem()
+		if err = setRawValue(keyInd, key); err != nil {
+			return fmt.Errorf("column `%s`: %v", columns[0], err)
+		}
+		elInd = reflect.New(mapTyp.Elem()).Elem()
+		if err = setRawValue(elInd, value); err != nil {
+			return fmt.Errorf("column `%s`: %v", columns[1], err)
+		}
+		m.SetMapIndex(keyInd, elInd)
+	}
+
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	ind.Set(m)
+
+	return nil
+}
+
 // return prepared raw statement for used in times.
 func (rq *rawQueryer) Prepare() (RawStmtQueryer, error) {
 	return newRawStmt(rq)
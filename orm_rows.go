@@ -0,0 +1,87 @@
+package orm
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+)
+
+// Rows is the cursor of QuerySetter results, models are scanned from database row by row.
+// for example:
+//	rows, err := qs.Rows()
+//	if err != nil {
+//		return err
+//	}
+//	defer rows.Close()
+//	for rows.Next() {
+//		user := new(User)
+//		if err = rows.Scan(user); err != nil {
+//			return err
+//		}
+//	}
+//	err = rows.Err()
+type Rows interface {
+	// prepare the next row for Scan, return false if no more rows or error happened
+	Next() bool
+	// scan current row to model, md must be a ptr of the QuerySetter's model
+	Scan(md interface{}) error
+	// return the error happened during iteration
+	Err() error
+	// close the rows, it's safe to call Close multiple times
+	Close() error
+}
+
+var _ Rows = new(modelRows)
+
+// model rows, a thin wrapper of *sql.Rows
+type modelRows struct {
+	mi          *modelInfo
+	rows        *sql.Rows
+	selectNames []string
+	annotations []annotation
+}
+
+// Next prepare the next row for Scan
+func (r *modelRows) Next() bool {
+	return r.rows.Next()
+}
+
+// Scan scan current row to model
+func (r *modelRows) Scan(md interface{}) error {
+	val := reflect.ValueOf(md)
+	ind := reflect.Indirect(val)
+
+	if val.Kind() != reflect.Ptr || r.mi.fullName != getFullName(ind.Type()) {
+		panic(fmt.Errorf("wrong object type `%s` for rows scan, need *%s", val.Type(), r.mi.fullName))
+	}
+
+	// clear the values of previous row
+	ind.Set(reflect.Zero(ind.Type()))
+
+	dynColumns, containers := r.mi.getScanContainers(ind, r.selectNames, r.annotations)
+	if err := r.rows.Scan(containers...); err != nil {
+		return err
+	}
+
+	return r.mi.setDynamicFields(ind, dynColumns)
+}
+
+// Err return the error happened during iteration
+func (r *modelRows) Err() error {
+	return r.rows.Err()
+}
+
+// Close close the rows
+func (r *modelRows) Close() error {
+	return r.rows.Close()
+}
+
+// newModelRows create new model rows
+func newModelRows(mi *modelInfo, rows *sql.Rows, selectNames []string, annotations []annotation) *modelRows {
+	return &modelRows{
+		mi:          mi,
+		rows:        rows,
+		selectNames: selectNames,
+		annotations: annotations,
+	}
+}
@@ -1,8 +1,10 @@
 package orm
 
 import (
+	"errors"
 	"os"
 	"strconv"
+	"sync"
 	"testing"
 	"time"
 
@@ -85,8 +87,25 @@ func TestInsert(t *testing.T) {
 		{PersonID: 11, Name: "multi_11"},
 	}
 
-	_, err = db.InsertMulti(10, persons)
+	count, err := db.InsertMulti(2, persons)
 	require.NoError(t, err, "insertMulti persons failed")
+	require.Equal(t, int64(3), count, "insertMulti count != 3")
+
+	// split by table suffix
+	persons = []*shardedPerson{
+		{PersonID: 4, Name: "multi_4"},
+		{PersonID: 5, Name: "multi_5"},
+		{PersonID: 8, Name: "multi_8"},
+		{PersonID: 9, Name: "multi_9"},
+		{PersonID: 13, Name: "multi_13"},
+	}
+	count, err = db.InsertMulti(2, persons)
+	require.NoError(t, err, "insertMulti persons of shards failed")
+	require.Equal(t, int64(5), count, "insertMulti count of shards != 5")
+
+	count, err = db.QueryTable(new(shardedPerson)).WithSuffix("1").Filter("Name__startswith", "multi_").Count()
+	require.NoError(t, err, "count persons of shard 1")
+	require.Equal(t, int64(2), count, "count persons of shard 1 != 2")
 
 	for i := 0; i < 4; i++ {
 		tableSuffix := strconv.Itoa(i)
@@ -197,6 +216,40 @@ func TestQueryTable(t *testing.T) {
 	require.NoError(t, err, "query count failed")
 	require.Equal(t, int64(2), count, "count != 2")
 
+	count, err = qs.Filter("Name__in", "zhangsan", "lisi").GroupBy("Name").Count()
+	require.NoError(t, err, "query count groups failed")
+	require.Equal(t, int64(2), count, "count groups != 2")
+
+	count, err = qs.CountDistinct("Age")
+	require.NoError(t, err, "query count distinct failed")
+	require.Equal(t, int64(2), count, "count distinct != 2")
+
+	exist, err := qs.Filter("Age", 10).Exist()
+	require.NoError(t, err, "query exist failed")
+	require.True(t, exist, "person of age 10 not exist")
+
+	exist, err = qs.Filter("Age", 100).Exist()
+	require.NoError(t, err, "query not exist failed")
+	require.False(t, exist, "person of age 100 exist")
+
+	// Subquery
+	count, err = qs.Filter("PersonID__in", qs.Filter("Name", "lisi").Select("PersonID")).Count()
+	require.NoError(t, err, "query count with in subquery failed")
+	require.Equal(t, int64(1), count, "count with in subquery != 1")
+
+	count, err = qs.Filter("__exists", qs.Filter("Age", 100)).Count()
+	require.NoError(t, err, "query count with exists subquery failed")
+	require.Equal(t, int64(0), count, "count with exists subquery != 0")
+
+	count, err = qs.Filter("__not_exists", qs.Filter("Age", 100)).Count()
+	require.NoError(t, err, "query count with not exists subquery failed")
+	require.Equal(t, int64(2), count, "count with not exists subquery != 2")
+
+	// Field expression
+	count, err = qs.Filter("PersonID__gt", F("Age").Mul(3)).Count()
+	require.NoError(t, err, "query count with field expression failed")
+	require.Equal(t, int64(1), count, "count with field expression != 1")
+
 	// ReadBatch
 	var persons []*shardedPerson
 	err = qs.OrderBy("-Age").All(&persons)
@@ -225,6 +278,19 @@ func TestQueryTable(t *testing.T) {
 	require.Equal(t, person2.Name, personOneAsc.Name, "check personOneAsc.Name")
 	require.Equal(t, person2.Age, personOneAsc.Age, "check personOneAsc.Age")
 
+	// Count after All and One on the same QuerySetter
+	readQs := qs.OrderBy("Age")
+	err = readQs.One(&personOneAsc)
+	require.NoError(t, err, "query one before count failed")
+	count, err = readQs.Count()
+	require.NoError(t, err, "query count after one failed")
+	require.Equal(t, int64(2), count, "count after one != 2")
+	err = readQs.All(&persons)
+	require.NoError(t, err, "query all before count failed")
+	count, err = readQs.Count()
+	require.NoError(t, err, "query count after all failed")
+	require.Equal(t, int64(2), count, "count after all != 2")
+
 	// UpateBatch
 	rowsAffected, err := qs.Update(Params{"Age": 18})
 	require.NoError(t, err, "update set age = 18 failed")
@@ -233,6 +299,10 @@ func TestQueryTable(t *testing.T) {
 	rowsAffected, err = qs.Filter("Age", 18).Update(Params{"Age": ColValue(ColSub, 1)})
 	require.NoError(t, err, "update set age = age -1 failed")
 	require.Equal(t, int64(2), rowsAffected, "rowsAffected != 2")
+	// UpdateBatch with field expression
+	rowsAffected, err = qs.Filter("Age", 17).Update(Params{"Age": F("Age").Add(F("Age")).Sub(17)})
+	require.NoError(t, err, "update set age = age + age - 17 failed")
+	require.Equal(t, int64(2), rowsAffected, "rowsAffected != 2")
 
 	// DeleteBatch
 	var personsUpdated []shardedPerson
@@ -250,6 +320,202 @@ func TestQueryTable(t *testing.T) {
 	require.Equal(t, int64(0), rowsDeleted, "rowsDeleted != 0")
 }
 
+func TestQueryAllShards(t *testing.T) {
+	db := NewOrm(zap.NewExample())
+	qs := db.QueryTable(new(shardedPerson)).AllShards(ShardSuffixes(4)...)
+	_, err := qs.Delete()
+	require.NoError(t, err, "clean all person tables")
+
+	for i := 1; i <= 8; i++ {
+		_, err = db.Insert(&shardedPerson{PersonID: int64(i), Name: "shard", Age: 10 * i})
+		require.NoError(t, err, "insert person")
+	}
+
+	qs = qs.Filter("Name", "shard")
+	count, err := qs.Count()
+	require.NoError(t, err, "count all shards")
+	require.Equal(t, int64(8), count, "count all shards != 8")
+
+	var persons []*shardedPerson
+	err = qs.OrderBy("-Age").Offset(1).Limit(3).All(&persons)
+	require.NoError(t, err, "query all shards")
+	require.Len(t, persons, 3)
+	require.Equal(t, []int{70, 60, 50}, []int{persons[0].Age, persons[1].Age, persons[2].Age})
+
+	person := shardedPerson{}
+	require.NoError(t, qs.OrderBy("Age").One(&person), "query one of all shards")
+	require.Equal(t, int64(1), person.PersonID)
+
+	rowsAffected, err := qs.Filter("Age__gte", 50).Update(Params{"Age": 0})
+	require.NoError(t, err, "update all shards")
+	require.Equal(t, int64(4), rowsAffected, "rowsAffected != 4")
+
+	_, err = qs.AllShards("0", "not_exist").Count()
+	shardErrs, ok := err.(ShardErrors)
+	require.True(t, ok, "error of not exist shard")
+	require.Len(t, shardErrs, 1)
+	require.Error(t, shardErrs["not_exist"])
+
+	rowsDeleted, err := qs.Delete()
+	require.NoError(t, err, "delete all shards")
+	require.Equal(t, int64(8), rowsDeleted, "rowsDeleted != 8")
+}
+
+func TestProvisionShards(t *testing.T) {
+	db := NewOrm(zap.NewExample())
+
+	result, err := ProvisionShards(new(shardedPerson), ProvisionSuffixes(ShardSuffixes(4)...))
+	require.NoError(t, err, "provision existing shards")
+	require.Empty(t, result.Created, "shards already exist")
+
+	result, err = ProvisionShards(new(shardedPerson), ProvisionSuffixes("provision"))
+	require.NoError(t, err, "provision new shard")
+	require.Equal(t, []string{"provision"}, result.Created)
+
+	result, err = ProvisionShards(new(shardedPerson), ProvisionSuffixes("provision"))
+	require.NoError(t, err, "provision new shard again")
+	require.Empty(t, result.Created, "provision is idempotent")
+
+	_, err = db.Raw("DROP TABLE `person_provision`").Exec()
+	require.NoError(t, err, "drop provisioned shard")
+}
+
+func TestQueryIterate(t *testing.T) {
+	db := NewOrm(zap.NewExample())
+	_, err := db.QueryTable(new(shardedPerson)).WithSuffix("1").Delete()
+	require.NoError(t, err, "clean person table")
+
+	for i := 0; i < 3; i++ {
+		_, err = db.Insert(&shardedPerson{PersonID: int64(4*i + 1), Name: "iter", Age: i})
+		require.NoError(t, err, "insert person")
+	}
+
+	qs := db.QueryTable(new(shardedPerson)).WithSuffix("1").OrderBy("Age")
+
+	// Rows
+	rows, err := qs.Rows()
+	require.NoError(t, err, "query rows failed")
+	ages := []int{}
+	for rows.Next() {
+		person := new(shardedPerson)
+		require.NoError(t, rows.Scan(person), "scan person")
+		ages = append(ages, person.Age)
+	}
+	require.NoError(t, rows.Err())
+	require.NoError(t, rows.Close())
+	require.Equal(t, []int{0, 1, 2}, ages)
+
+	// Iterate
+	ages = ages[:0]
+	err = qs.Iterate(func(md interface{}) error {
+		ages = append(ages, md.(*shardedPerson).Age)
+		return nil
+	})
+	require.NoError(t, err, "iterate failed")
+	require.Equal(t, []int{0, 1, 2}, ages)
+
+	// Iterate stops on error
+	errStop := errors.New("stop")
+	count := 0
+	err = qs.Iterate(func(md interface{}) error {
+		count++
+		return errStop
+	})
+	require.Equal(t, errStop, err)
+	require.Equal(t, 1, count)
+
+	_, err = db.QueryTable(new(shardedPerson)).WithSuffix("1").Delete()
+	require.NoError(t, err, "clean person table")
+}
+
+func TestPaginateAfter(t *testing.T) {
+	db := NewOrm(zap.NewExample())
+	_, err := db.QueryTable(new(shardedPerson)).WithSuffix("2").Delete()
+	require.NoError(t, err, "clean person table")
+
+	for i := 0; i < 5; i++ {
+		_, err = db.Insert(&shardedPerson{PersonID: int64(4*i + 2), Name: "page", Age: i % 2})
+		require.NoError(t, err, "insert person")
+	}
+
+	var (
+		qs      = db.QueryTable(new(shardedPerson)).WithSuffix("2").Filter("Name", "page").OrderBy("-Age")
+		persons []*shardedPerson
+		ids     = map[int64]bool{}
+		cursor  string
+		pages   int
+	)
+
+	for {
+		cursor, err = qs.PaginateAfter(cursor, 2, &persons)
+		require.NoError(t, err, "paginate failed")
+		pages++
+		for _, person := range persons {
+			require.False(t, ids[person.ID], "duplicated person in pages")
+			ids[person.ID] = true
+		}
+		if cursor == "" {
+			break
+		}
+	}
+	require.Equal(t, 3, pages)
+	require.Equal(t, 5, len(ids))
+
+	_, err = qs.PaginateAfter("invalid", 2, &persons)
+	require.Equal(t, ErrInvalidCursor, err)
+
+	_, err = db.QueryTable(new(shardedPerson)).WithSuffix("2").Delete()
+	require.NoError(t, err, "clean person table")
+}
+
+func TestForEachChunk(t *testing.T) {
+	db := NewOrm(zap.NewExample())
+	suffixes := []string{"0", "1", "2", "3"}
+	for _, suffix := range suffixes {
+		_, err := db.QueryTable(new(shardedPerson)).WithSuffix(suffix).Delete()
+		require.NoError(t, err, "clean person table")
+	}
+
+	for i := 0; i < 10; i++ {
+		_, err := db.Insert(&shardedPerson{PersonID: int64(i), Name: "chunk", Age: i})
+		require.NoError(t, err, "insert person")
+	}
+
+	var (
+		mu          sync.Mutex
+		ages        = map[int]bool{}
+		checkpoints []ChunkCheckpoint
+	)
+	err := db.QueryTable(new(shardedPerson)).Filter("Name", "chunk").ForEachChunk(2, func(batch interface{}) error {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, person := range batch.([]*shardedPerson) {
+			ages[person.Age] = true
+		}
+		return nil
+	}, ChunkWorkers(3), ChunkShards(suffixes...), ChunkCheckpointFunc(func(cp ChunkCheckpoint) error {
+		checkpoints = append(checkpoints, cp)
+		return nil
+	}))
+	require.NoError(t, err, "for each chunk failed")
+	require.Equal(t, 10, len(ages))
+	require.Equal(t, "3", checkpoints[len(checkpoints)-1].TableSuffix)
+
+	// resume from the beginning of the last shard
+	count := 0
+	err = db.QueryTable(new(shardedPerson)).ForEachChunk(2, func(batch interface{}) error {
+		count += len(batch.([]*shardedPerson))
+		return nil
+	}, ChunkShards(suffixes...), ChunkResume(ChunkCheckpoint{TableSuffix: "3", LastPk: int64(0)}))
+	require.NoError(t, err, "resume for each chunk failed")
+	require.Equal(t, 2, count)
+
+	for _, suffix := range suffixes {
+		_, err = db.QueryTable(new(shardedPerson)).WithSuffix(suffix).Delete()
+		require.NoError(t, err, "clean person table")
+	}
+}
+
 func TestJsonOmit(t *testing.T) {
 	db := NewOrm(zap.NewExample())
 	db.QueryTable(new(anyObj)).Delete()
@@ -322,6 +588,64 @@ func TestRawQueryRows(t *testing.T) {
 	require.Equal(t, 100, objs[0].Obj.Value)
 }
 
+type anyObjStat struct {
+	ObjID   int64 `orm:"column(id)"`
+	Obj     obj   `orm:"json"`
+	Counter int64
+}
+
+func TestRawQueryRowsUnregistered(t *testing.T) {
+	db := NewOrm(zap.NewExample())
+	db.QueryTable(new(anyObj)).Delete()
+
+	obj := &anyObj{
+		ID:  20,
+		Obj: obj{"helloworld", 100},
+	}
+	_, err := db.Insert(obj)
+	require.NoError(t, err)
+
+	var stats []*anyObjStat
+	err = db.Raw("select id, obj, 3 as counter, 'x' as unknown from any_obj where id = ?", 20).QueryRows(&stats)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(stats))
+	require.Equal(t, int64(20), stats[0].ObjID)
+	require.Equal(t, "helloworld", stats[0].Obj.Name)
+	require.Equal(t, int64(3), stats[0].Counter)
+}
+
+func TestRawQueryRowsValues(t *testing.T) {
+	db := NewOrm(zap.NewExample())
+	db.QueryTable(new(anyObj)).Delete()
+
+	for _, id := range []int64{30, 31} {
+		_, err := db.Insert(&anyObj{ID: id, Obj: obj{"helloworld", int(id)}})
+		require.NoError(t, err)
+	}
+
+	var ids []int64
+	err := db.Raw("select id from any_obj order by id").QueryRows(&ids)
+	require.NoError(t, err)
+	require.Equal(t, []int64{30, 31}, ids)
+
+	var strIDs []string
+	err = db.Raw("select id from any_obj order by id").QueryRows(&strIDs)
+	require.NoError(t, err)
+	require.Equal(t, []string{"30", "31"}, strIDs)
+
+	var rows []map[string]interface{}
+	err = db.Raw("select id, obj_omit from any_obj where id = ?", 30).QueryRows(&rows)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(rows))
+	require.Equal(t, "", rows[0]["obj_omit"])
+
+	lookup := map[int64]string{}
+	err = db.Raw("select id, obj from any_obj").QueryRows(&lookup)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(lookup))
+	require.Equal(t, `{"name":"helloworld","value":31}`, lookup[31])
+}
+
 type timeObj struct {
 	ID      int       `orm:"column(id);pk;auto"`
 	ObjTime time.Time `orm:"column(obj_time)"`
@@ -354,6 +678,7 @@ func TestMain(m *testing.M) {
 	RegisterModel("default", new(dynamicModel))
 	RegisterModel("default", new(anyObj))
 	RegisterModel("default", new(timeObj))
+	SetCursorSecret([]byte("orm_test"))
 	DebugSQLBuilder = true
 	devLogger, _ := zap.NewDevelopment()
 	SetDefaultLogger(devLogger)
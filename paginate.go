@@ -0,0 +1,267 @@
+package orm
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+var cursorSecret []byte
+
+// SetCursorSecret set the key used to sign the pagination cursors, it's required before PaginateAfter.
+// the key must be the same in all the processes serving the cursors, e.g. the replicas of service,
+// and the cursors signed by the previous key are invalid after the key is changed.
+func SetCursorSecret(secret []byte) {
+	if len(secret) == 0 {
+		panic(fmt.Errorf("<SetCursorSecret> secret cannot empty"))
+	}
+	cursorSecret = secret
+}
+
+// seek key of keyset pagination
+type seekKey struct {
+	fi   *fieldInfo
+	desc bool
+}
+
+// order returns the order expression of seek key, e.g. "-column"
+func (k seekKey) order() string {
+	if k.desc {
+		return "-" + k.fi.column
+	}
+	return k.fi.column
+}
+
+// pagination cursor content
+type cursorToken struct {
+	Model  string            `json:"m"`
+	Orders []string          `json:"o"`
+	Values []json.RawMessage `json:"v"`
+}
+
+// getSeekKeys return the seek keys of orders, the pk is appended to make the order unique.
+// the nullable field cannot be used, as the rows after NULL cannot be compared by the seek condition.
+func (mi *modelInfo) getSeekKeys(orders []string) []seekKey {
+	keys := make([]seekKey, 0, len(orders)+1)
+	hasPk := false
+	for _, order := range orders {
+		desc := false
+		switch order[0] {
+		case '-':
+			desc = true
+			order = order[1:]
+		case '+':
+			order = order[1:]
+		}
+
+		exprs := strings.Split(order, ExprSep)
+		fi, _, ok := mi.parseExprs(exprs)
+		if !ok {
+			panic(fmt.Errorf("unknown field/column name `%s`", strings.Join(exprs, ExprSep)))
+		}
+		if fi.json {
+			panic(fmt.Errorf("json field `%s` cannot be used in pagination order", fi.fullName))
+		}
+		if fi.isNullable() {
+			panic(fmt.Errorf("nullable field `%s` cannot be used in pagination order", fi.fullName))
+		}
+		if fi.pk {
+			hasPk = true
+		}
+		keys = append(keys, seekKey{fi: fi, desc: desc})
+	}
+
+	if !hasPk {
+		desc := len(keys) > 0 && keys[len(keys)-1].desc
+		keys = append(keys, seekKey{fi: mi.fields.pk, desc: desc})
+	}
+	return keys
+}
+
+// getSeekCond return the condition of rows after values in the order of keys
+func getSeekCond(keys []seekKey, values []interface{}) *Condition {
+	sameDirection := true
+	for _, key := range keys[1:] {
+		if key.desc != keys[0].desc {
+			sameDirection = false
+			break
+		}
+	}
+
+	if sameDirection {
+		names := make([]string, len(keys))
+		for i, key := range keys {
+			names[i] = key.fi.name
+		}
+		return NewCondition().andTuple(names, values, keys[0].desc)
+	}
+
+	// mixed directions, expands to: a > ? OR (a = ? AND b < ?) OR ...
+	cond := NewCondition()
+	for i, key := range keys {
+		branch := NewCondition()
+		for j := 0; j < i; j++ {
+			branch = branch.And(keys[j].fi.name, values[j])
+		}
+		operator := "gt"
+		if key.desc {
+			operator = "lt"
+		}
+		branch = branch.And(key.fi.name+ExprSep+operator, values[i])
+		cond = cond.OrCond(branch)
+	}
+	return cond
+}
+
+// encodeCursor return the signed cursor of the row ind
+func (mi *modelInfo) encodeCursor(keys []seekKey, ind reflect.Value) (string, error) {
+	token := cursorToken{
+		Model:  mi.fullName,
+		Orders: make([]string, len(keys)),
+		Values: make([]json.RawMessage, len(keys)),
+	}
+
+	for i, key := range keys {
+		data, err := json.Marshal(ind.FieldByIndex(key.fi.fieldIndex).Interface())
+		if err != nil {
+			return "", err
+		}
+		token.Orders[i] = key.order()
+		token.Values[i] = data
+	}
+
+	payload, err := json.Marshal(&token)
+	if err != nil {
+		return "", err
+	}
+
+	encoding := base64.RawURLEncoding
+	return encoding.EncodeToString(payload) + "." + encoding.EncodeToString(signCursor(payload)), nil
+}
+
+// decodeCursor verify the cursor and return the values of keys
+func (mi *modelInfo) decodeCursor(cursor string, keys []seekKey) ([]interface{}, error) {
+	encoding := base64.RawURLEncoding
+
+	i := strings.IndexByte(cursor, '.')
+	if i < 0 {
+		return nil, ErrInvalidCursor
+	}
+	payload, err := encoding.DecodeString(cursor[:i])
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	sign, err := encoding.DecodeString(cursor[i+1:])
+	if err != nil || !hmac.Equal(sign, signCursor(payload)) {
+		return nil, ErrInvalidCursor
+	}
+
+	var token cursorToken
+	if err = json.Unmarshal(payload, &token); err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	// the cursor must be created by the same model with the same order
+	if token.Model != mi.fullName || len(token.Orders) != len(keys) || len(token.Values) != len(keys) {
+		return nil, ErrInvalidCursor
+	}
+
+	values := make([]interface{}, len(keys))
+	for i, key := range keys {
+		if token.Orders[i] != key.order() || string(token.Values[i]) == "null" {
+			return nil, ErrInvalidCursor
+		}
+		value := reflect.New(key.fi.sf.Type)
+		if err = json.Unmarshal(token.Values[i], value.Interface()); err != nil {
+			return nil, ErrInvalidCursor
+		}
+		values[i] = value.Elem().Interface()
+	}
+	return values, nil
+}
+
+func signCursor(payload []byte) []byte {
+	mac := hmac.New(sha256.New, cursorSecret)
+	// nolint:errcheck
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// PaginateAfter query a page of rows after cursor and map to container.
+// nolint:lll
+func (qs *querySetter) PaginateAfter(cursor string, pageSize int, container interface{}, cols ...string) (string, error) {
+	qs.checkNoShards("PaginateAfter")
+	if len(cursorSecret) == 0 {
+		panic(fmt.Errorf("<QuerySeter.PaginateAfter> cursor secret is not set, call SetCursorSecret at startup"))
+	}
+	if pageSize <= 0 {
+		panic(fmt.Errorf("<QuerySeter.PaginateAfter> pageSize must be positive, but got %d", pageSize))
+	}
+
+	var (
+		keys   = qs.mi.getSeekKeys(qs.orders)
+		orders = make([]string, len(keys))
+		pageQs = *qs
+	)
+
+	for i, key := range keys {
+		orders[i] = key.order()
+	}
+	pageQs.orders = orders
+	pageQs.offset = 0
+	pageQs.limit = pageSize + 1
+
+	if cursor != "" {
+		values, err := qs.mi.decodeCursor(cursor, keys)
+		if err != nil {
+			return "", err
+		}
+		seekCond := getSeekCond(keys, values)
+		if qs.cond != nil && !qs.cond.IsEmpty() {
+			seekCond = NewCondition().AndCond(qs.cond).AndCond(seekCond)
+		}
+		pageQs.cond = seekCond
+	}
+
+	// the seek keys must be selected to build the next cursor
+	cols = qs.getSelectNames(cols)
+	if len(cols) > 0 {
+		for _, key := range keys {
+			found := false
+			for _, col := range cols {
+				if fi, ok := qs.mi.fields.GetByAny(col); ok && fi == key.fi {
+					found = true
+					break
+				}
+			}
+			if !found {
+				cols = append(cols, key.fi.column)
+			}
+		}
+	}
+
+	// the container is reused between pages
+	ind := reflect.Indirect(reflect.ValueOf(container))
+	if ind.Kind() == reflect.Slice && ind.CanSet() {
+		ind.Set(reflect.Zero(ind.Type()))
+	}
+
+	db, err := pageQs.routeDB()
+	if err != nil {
+		return "", err
+	}
+	if err = qs.mi.ReadBatch(qs.orm.ctx, db, &pageQs, pageQs.cond, container, cols); err != nil {
+		return "", err
+	}
+
+	if ind.Len() <= pageSize {
+		return "", nil
+	}
+
+	ind.Set(ind.Slice(0, pageSize))
+	return qs.mi.encodeCursor(keys, reflect.Indirect(ind.Index(pageSize-1)))
+}
@@ -0,0 +1,73 @@
+package orm
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeekCond(t *testing.T) {
+	mi := newModelInfo(reflect.ValueOf(&Person{}))
+
+	keys := mi.getSeekKeys([]string{"Name"})
+	require.Equal(t, 2, len(keys), "pk is appended")
+	sql := getSeekCond(keys, []interface{}{"zhang", 10}).GetWhereSQL(mi, newSqlBuilderCond())
+	require.Equal(t, "(`name`, `id`) > ($0, $1)", sql)
+
+	keys = mi.getSeekKeys([]string{"-Name", "-ID"})
+	require.Equal(t, 2, len(keys), "pk is not appended twice")
+	sql = getSeekCond(keys, []interface{}{"zhang", 10}).GetWhereSQL(mi, newSqlBuilderCond())
+	require.Equal(t, "(`name`, `id`) < ($0, $1)", sql)
+
+	keys = mi.getSeekKeys([]string{"-Name", "ID"})
+	sql = getSeekCond(keys, []interface{}{"zhang", 10}).GetWhereSQL(mi, newSqlBuilderCond())
+	require.Equal(t, "(`name` < $0) OR (`name` = $1 AND `id` > $2)", sql)
+
+	// the rows after NULL cannot be compared
+	mi = newModelInfo(reflect.ValueOf(&nullablePerson{}))
+	require.Panics(t, func() { mi.getSeekKeys([]string{"Nickname"}) }, "pointer field")
+	require.Panics(t, func() { mi.getSeekKeys([]string{"-Age"}) }, "null tag")
+	require.Len(t, mi.getSeekKeys([]string{"Name"}), 2)
+}
+
+type nullablePerson struct {
+	ID       int64   `orm:"pk;column(id)"`
+	Name     string  `orm:"column(name)"`
+	Nickname *string `orm:"column(nickname)"`
+	Age      int     `orm:"column(age);null"`
+}
+
+func TestCursor(t *testing.T) {
+	mi := newModelInfo(reflect.ValueOf(&Person{}))
+	keys := mi.getSeekKeys([]string{"-Name"})
+
+	cursor, err := mi.encodeCursor(keys, reflect.ValueOf(Person{ID: 10, Name: "zhang"}))
+	require.NoError(t, err)
+
+	values, err := mi.decodeCursor(cursor, keys)
+	require.NoError(t, err)
+	require.Equal(t, []interface{}{"zhang", int64(10)}, values)
+
+	_, err = mi.decodeCursor(cursor, mi.getSeekKeys([]string{"Name"}))
+	require.Equal(t, ErrInvalidCursor, err, "cursor of another order")
+
+	other := newModelInfo(reflect.ValueOf(&nullablePerson{}))
+	_, err = other.decodeCursor(cursor, other.getSeekKeys([]string{"-Name"}))
+	require.Equal(t, ErrInvalidCursor, err, "cursor of another model with the same order")
+
+	tampered := []byte(cursor)
+	tampered[0]++
+	_, err = mi.decodeCursor(string(tampered), keys)
+	require.Equal(t, ErrInvalidCursor, err, "tampered cursor")
+
+	secret := cursorSecret
+	SetCursorSecret([]byte("another secret"))
+	_, err = mi.decodeCursor(cursor, keys)
+	SetCursorSecret(secret)
+	require.Equal(t, ErrInvalidCursor, err, "cursor signed by another secret")
+
+	cursorSecret = nil
+	require.Panics(t, func() { (&querySetter{mi: mi}).PaginateAfter("", 10, &[]Person{}) }, "secret is not set")
+	SetCursorSecret(secret)
+}
@@ -5,6 +5,31 @@ import "fmt"
 // Params stores the Params
 type Params map[string]interface{}
 
+// Merge return a new Params with p and others, the later values overwrite the former ones,
+// but the json mutators of the same field are applied in order. usage:
+//	params := orm.Params{"Status": 1}.Merge(
+//		orm.JSONSet("Content", "$.user.name", "slene"),
+//		orm.JSONRemove("Content", "$.user.age"),
+//	)
+func (p Params) Merge(others ...Params) Params {
+	merged := make(Params, len(p))
+	for name, value := range p {
+		merged[name] = value
+	}
+	for _, other := range others {
+		for name, value := range other {
+			if m, ok := value.(*jsonMutation); ok {
+				if prev, ok := merged[name].(*jsonMutation); ok {
+					ops := make([]jsonOp, 0, len(prev.ops)+len(m.ops))
+					value = &jsonMutation{ops: append(append(ops, prev.ops...), m.ops...)}
+				}
+			}
+			merged[name] = value
+		}
+	}
+	return merged
+}
+
 // ParamsList stores paramslist
 type ParamsList []interface{}
 
@@ -1,9 +1,24 @@
 package orm
 
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/std0d9k81/orm/sqlbuilder"
+)
+
 // QuerySetter is the advanced query interface.
 type QuerySetter interface {
-	// WithSuffix specifies the table suffix
+	// WithSuffix specifies the table suffix.
+	// it's not required for model registered by RegisterShardedModel if the shard key is filtered by equality or in,
+	// otherwise the query of sharded model returns ErrNoTableSuffix without WithSuffix or AllShards.
 	WithSuffix(tableSuffix string) QuerySetter
+	// query all the sharded tables with suffixes concurrently, the suffixes of shard strategy are used if not specified.
+	// the rows of All and One are merged, sorted by OrderBy in memory and then Offset and Limit are applied.
+	// Count, Update and Delete return the sum of all shards, the errors of shards are returned together as ShardErrors.
+	// for example:
+	//	qs.AllShards(orm.ShardSuffixes(16)...).Filter("Status", 1).OrderBy("-Created").Limit(20).All(&orders)
+	AllShards(suffixes ...string) QuerySetter
 	// Set Distinct
 	// for example:
 	//  o.QueryTable("policy").Filter("Groups__Group__Users__User", user).
@@ -18,6 +33,12 @@ type QuerySetter interface {
 	//	Filter("profile__Age", 28)
 	// 	 // time compare
 	//	qs.Filter("created", time.Now())
+	//	 // subquery, a QuerySetter or sqlbuilder.Builder
+	//	qs.Filter("UserID__in", o.QueryTable("user").Filter("Status", 1))
+	//	qs.Filter("__exists", o.QueryTable("post").Filter("Status", 1))
+	//	 // path of json field
+	//	qs.Filter("Content__json__user__id", 42)
+	//	qs.Filter("Tags__json_contains", "vip")
 	Filter(string, ...interface{}) QuerySetter
 	// add NOT condition to querySeter.
 	// have the same usage as Filter
@@ -43,8 +64,22 @@ type QuerySetter interface {
 	// for example:
 	//	qs.GroupBy("id")
 	GroupBy(exprs ...string) QuerySetter
+	// set the default columns when querying, they are used if no cols are given to All, One, Rows...
+	// it's also the selected column when the QuerySetter is used as a subquery, the pk is selected by default.
+	// for example:
+	//	users := o.QueryTable("user").Filter("Status", 1).Select("ID")
+	//	num, err := o.QueryTable("post").Filter("UserID__in", users).Count()
+	//	//sql-> ... WHERE `user_id` IN (SELECT `id` FROM `user` WHERE `status` = ?)
+	Select(cols ...string) QuerySetter
+	// add the expression as a selected column named alias, it can be used in OrderBy.
+	// the value is scanned to the field named alias if the model has, otherwise it's discarded.
+	// for example:
+	//	qs.Filter("__search", orm.Match("Title", "Body").Against("golang")).
+	//		Annotate("Score", orm.Match("Title", "Body").Against("golang")).
+	//		OrderBy("-Score")
+	Annotate(alias string, expr Expr) QuerySetter
 	// add ORDER expression.
-	// "column" means ASC, "-column" means DESC.
+	// "column" means ASC, "-column" means DESC, json field can be ordered by path, e.g. "-Content__json__user__age".
 	// for example:
 	//	qs.OrderBy("-status")
 	OrderBy(exprs ...string) QuerySetter
@@ -54,12 +89,17 @@ type QuerySetter interface {
 	Limit(limit int) QuerySetter
 	// for update
 	ForUpdate() QuerySetter
-	// return QuerySetter execution result number
+	// return QuerySetter execution result number.
+	// Distinct, GroupBy, Limit and Offset are honoured, e.g. the number of groups is returned with GroupBy.
 	// for example:
 	//	num, err = qs.Filter("profile__age__gt", 28).Count()
 	Count() (int64, error)
+	// return the number of distinct values of cols
+	// for example:
+	//	num, err = qs.Filter("profile__age__gt", 28).CountDistinct("City")
+	CountDistinct(cols ...string) (int64, error)
 	// check result empty or not after QuerySetter executed
-	// the same as QuerySetter.Count > 0
+	// the same as QuerySetter.Count > 0, but stops at the first row
 	Exist() (bool, error)
 	// execute update with parameters
 	// for example:
@@ -69,6 +109,8 @@ type QuerySetter interface {
 	//	num, err = qs.Filter("UserName", "slene").Update(Params{
 	//		"user_name": "slene2"
 	//	}) // user slene's  name will change to slene2
+	//	num, err = qs.Filter("UserName", "slene").Update(orm.JSONSet("Profile", "$.address.city", "Beijing"))
+	//	// only the city in json field Profile is changed
 	Update(values Params) (int64, error)
 	// delete from table
 	//for example:
@@ -95,6 +137,51 @@ type QuerySetter interface {
 	//	var user User
 	//	qs.One(&user) //user.UserName == "slene"
 	One(container interface{}, cols ...string) error
+	// return a cursor of the query results, models are read from database row by row.
+	// unlike All, DefaultLimit is not applied.
+	// cols means the columns when querying.
+	// for example:
+	//	rows, err := qs.Rows()
+	//	defer rows.Close()
+	//	for rows.Next() {
+	//		user := new(User)
+	//		err = rows.Scan(user)
+	//	}
+	//	err = rows.Err()
+	Rows(cols ...string) (Rows, error)
+	// query all data and call fn with every model, models are read from database row by row.
+	// unlike All, DefaultLimit is not applied.
+	// the iteration stops if fn returns an error, and the error is returned.
+	// for example:
+	//	err = qs.Iterate(func(md interface{}) error {
+	//		user := md.(*User)
+	//		return nil
+	//	})
+	Iterate(fn func(md interface{}) error, cols ...string) error
+	// query a page of data after cursor and map to containers, it's the keyset pagination.
+	// rows are ordered by the OrderBy expressions plus the pk, Offset and Limit are ignored.
+	// cursor is the opaque token returned by the previous page, empty cursor means the first page.
+	// the cursor is signed by the key of SetCursorSecret, and accepted only by the same model and orders.
+	// the nullable fields cannot be used in OrderBy of pagination.
+	// next is empty if there are no more pages.
+	// cols means the columns when querying.
+	// for example:
+	//	var users []*User
+	//	next, err := qs.OrderBy("-Created").PaginateAfter("", 20, &users)
+	//	next, err = qs.OrderBy("-Created").PaginateAfter(next, 20, &users)
+	PaginateAfter(cursor string, pageSize int, container interface{}, cols ...string) (next string, err error)
+	// walk all rows matching the condition by the pk in chunks of size, and call fn with every chunk.
+	// batch is a slice of model ptr, e.g. []*User. every chunk is read with a standalone query,
+	// so no long transaction is held. the walking stops if fn returns an error, and the error is returned.
+	// options can process chunks in parallel, walk all sharded tables, and save/resume the checkpoint.
+	// for example:
+	//	err = qs.Filter("Status", 0).ForEachChunk(500, func(batch interface{}) error {
+	//		for _, user := range batch.([]*User) {
+	//			...
+	//		}
+	//		return nil
+	//	}, orm.ChunkWorkers(4), orm.ChunkShards("0", "1", "2", "3"), orm.ChunkCheckpointFunc(save))
+	ForEachChunk(size int, fn func(batch interface{}) error, options ...ChunkOption) error
 }
 
 var _ QuerySetter = new(querySetter)
@@ -107,9 +194,12 @@ type querySetter struct {
 	mi          *modelInfo
 	cond        *Condition
 	tableSuffix string
+	shards      []string
 	limit       int
 	offset      int
 	orders      []string
+	selects     []string
+	annotations []annotation
 	groups      []string
 	distinct    bool
 	forUpdate   bool
@@ -123,6 +213,62 @@ func (qs querySetter) WithSuffix(tableSuffix string) QuerySetter {
 	return &qs
 }
 
+// AllShards query all the sharded tables with suffixes
+func (qs querySetter) AllShards(suffixes ...string) QuerySetter {
+	if len(suffixes) == 0 {
+		if qs.mi.shardStrategy == nil {
+			panic(fmt.Errorf("<QuerySeter.AllShards> suffixes cannot empty for model `%s` without shard strategy", qs.mi.fullName))
+		}
+		suffixes = qs.mi.shardStrategy.Suffixes()
+	}
+	qs.shards = suffixes
+	return &qs
+}
+
+// checkNoShards panic if AllShards is used by the method which cannot be merged across shards
+func (qs *querySetter) checkNoShards(method string) {
+	if len(qs.shards) > 0 {
+		panic(fmt.Errorf("<QuerySeter.%s> cannot be used with AllShards", method))
+	}
+}
+
+// routeDB return the db queryer of the table queried, the tables of subqueries in filters are checked too
+func (qs *querySetter) routeDB() (dbQueryer, error) {
+	tableSuffix, err := qs.getTableSuffix()
+	if err != nil {
+		return nil, err
+	}
+	if err = qs.cond.checkSubqueries(); err != nil {
+		return nil, err
+	}
+	return qs.orm.routeDB(qs.mi, tableSuffix)
+}
+
+// getTableSuffix return the suffix set by WithSuffix, otherwise derived from the equality or in filter of shard key.
+// ErrNoTableSuffix is returned for the sharded model if the suffix cannot be derived.
+func (qs *querySetter) getTableSuffix() (string, error) {
+	if qs.tableSuffix != "" {
+		return qs.tableSuffix, nil
+	}
+	tableSuffix, err := qs.mi.getShardSuffixByCond(qs.cond)
+	if err != nil {
+		return "", err
+	}
+	if qs.mi.sharded && tableSuffix == "" {
+		return "", ErrNoTableSuffix(qs.mi.table)
+	}
+	return tableSuffix, nil
+}
+
+// getTable return the table queried
+func (qs *querySetter) getTable() (string, error) {
+	tableSuffix, err := qs.getTableSuffix()
+	if err != nil {
+		return "", err
+	}
+	return qs.mi.getTableBySuffix(tableSuffix), nil
+}
+
 // ForceMaster force query in master node by add hint `{"router":"m"}`
 func (qs querySetter) ForceMaster() QuerySetter {
 	qs.forceMaster = true
@@ -160,6 +306,22 @@ func (qs querySetter) OrderBy(exprs ...string) QuerySetter {
 	return &qs
 }
 
+// Select set the default columns when querying
+func (qs querySetter) Select(cols ...string) QuerySetter {
+	qs.selects = cols
+	return &qs
+}
+
+// Annotate add the expression as a selected column named alias
+func (qs querySetter) Annotate(alias string, expr Expr) QuerySetter {
+	if alias == "" || expr == nil {
+		panic(fmt.Errorf("<QuerySeter.Annotate> alias and expr cannot empty"))
+	}
+	annotations := make([]annotation, 0, len(qs.annotations)+1)
+	qs.annotations = append(append(annotations, qs.annotations...), annotation{alias: alias, expr: expr})
+	return &qs
+}
+
 // GroupBy add GROUP expression
 func (qs querySetter) GroupBy(exprs ...string) QuerySetter {
 	qs.groups = exprs
@@ -198,23 +360,60 @@ func (qs *querySetter) GetCond() *Condition {
 
 // Count return QuerySetter execution result number
 func (qs *querySetter) Count() (int64, error) {
-	return qs.mi.Count(qs.orm.ctx, qs.orm.db, qs, qs.cond)
+	if len(qs.shards) > 0 {
+		return qs.countShards()
+	}
+	db, err := qs.routeDB()
+	if err != nil {
+		return 0, err
+	}
+	return qs.mi.Count(qs.orm.ctx, db, qs, qs.cond)
+}
+
+// CountDistinct return the number of distinct values of cols
+func (qs *querySetter) CountDistinct(cols ...string) (int64, error) {
+	qs.checkNoShards("CountDistinct")
+	db, err := qs.routeDB()
+	if err != nil {
+		return 0, err
+	}
+	return qs.mi.CountDistinct(qs.orm.ctx, db, qs, qs.cond, cols)
 }
 
 // Exist check result empty or not after QuerySetter executed
 func (qs *querySetter) Exist() (bool, error) {
-	cnt, err := qs.mi.Count(qs.orm.ctx, qs.orm.db, qs, qs.cond)
-	return cnt > 0, err
+	if len(qs.shards) > 0 {
+		return qs.existShards()
+	}
+	db, err := qs.routeDB()
+	if err != nil {
+		return false, err
+	}
+	return qs.mi.Exist(qs.orm.ctx, db, qs, qs.cond)
 }
 
 // Update execute update with parameters
 func (qs *querySetter) Update(params Params) (int64, error) {
-	return qs.mi.UpdateBatch(qs.orm.ctx, qs.orm.db, qs, qs.cond, params)
+	if len(qs.shards) > 0 {
+		return qs.updateShards(params)
+	}
+	db, err := qs.routeDB()
+	if err != nil {
+		return 0, err
+	}
+	return qs.mi.UpdateBatch(qs.orm.ctx, db, qs, qs.cond, params)
 }
 
 // Delete execute delete
 func (qs *querySetter) Delete() (int64, error) {
-	return qs.mi.DeleteBatch(qs.orm.ctx, qs.orm.db, qs, qs.cond)
+	if len(qs.shards) > 0 {
+		return qs.deleteShards()
+	}
+	db, err := qs.routeDB()
+	if err != nil {
+		return 0, err
+	}
+	return qs.mi.DeleteBatch(qs.orm.ctx, db, qs, qs.cond)
 }
 
 // return a insert queryer.
@@ -225,23 +424,101 @@ func (qs *querySetter) Delete() (int64, error) {
 //	 num, err = i.Insert(&user2) // user table will add one record user2 at once
 //	 err = i.Close() //don't forget call Close
 func (qs *querySetter) PrepareInsert() (Inserter, error) {
+	qs.checkNoShards("PrepareInsert")
 	return newPreparedInserter(qs.orm, qs.mi, qs.tableSuffix)
 }
 
 // All query all data and map to containers.
 // cols means the columns when querying.
 func (qs *querySetter) All(container interface{}, cols ...string) error {
-	if qs.limit == 0 && DefaultLimit != 0 {
-		qs.limit = DefaultLimit
+	// the default limit is set on a copy, the receiver may be used by Count later
+	readQs := *qs
+	if readQs.limit == 0 && DefaultLimit != 0 {
+		readQs.limit = DefaultLimit
 	}
-	return qs.mi.ReadBatch(qs.orm.ctx, qs.orm.db, qs, qs.cond, container, cols)
+	if len(readQs.shards) > 0 {
+		return readQs.readShards("All", container, readQs.getSelectNames(cols))
+	}
+	db, err := readQs.routeDB()
+	if err != nil {
+		return err
+	}
+	return readQs.mi.ReadBatch(readQs.orm.ctx, db, &readQs, readQs.cond, container, readQs.getSelectNames(cols))
 }
 
 // One query one row data and map to containers.
 // cols means the columns when querying.
 func (qs *querySetter) One(container interface{}, cols ...string) error {
-	qs.limit = 1
-	return qs.mi.ReadOne(qs.orm.ctx, qs.orm.db, qs, qs.cond, container, cols)
+	readQs := *qs
+	readQs.limit = 1
+	if len(readQs.shards) > 0 {
+		return readQs.oneShards(container, readQs.getSelectNames(cols))
+	}
+	db, err := readQs.routeDB()
+	if err != nil {
+		return err
+	}
+	return readQs.mi.ReadOne(readQs.orm.ctx, db, &readQs, readQs.cond, container, readQs.getSelectNames(cols))
+}
+
+// Rows return a cursor of the query results.
+// cols means the columns when querying.
+func (qs *querySetter) Rows(cols ...string) (Rows, error) {
+	qs.checkNoShards("Rows")
+	db, err := qs.routeDB()
+	if err != nil {
+		return nil, err
+	}
+	return qs.mi.ReadRows(qs.orm.ctx, db, qs, qs.cond, qs.getSelectNames(cols))
+}
+
+// Iterate call fn with every model of the query results.
+// cols means the columns when querying.
+func (qs *querySetter) Iterate(fn func(md interface{}) error, cols ...string) (err error) {
+	qs.checkNoShards("Iterate")
+	db, err := qs.routeDB()
+	if err != nil {
+		return err
+	}
+	rows, err := qs.mi.ReadRows(qs.orm.ctx, db, qs, qs.cond, qs.getSelectNames(cols))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := rows.Close(); err == nil {
+			err = cerr
+		}
+	}()
+
+	for rows.Next() {
+		md := reflect.New(qs.mi.addrField.Elem().Type()).Interface()
+		if err = rows.Scan(md); err != nil {
+			return err
+		}
+		if err = fn(md); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// getSelectNames return cols, or the default columns set by Select if cols is empty
+func (qs *querySetter) getSelectNames(cols []string) []string {
+	if len(cols) > 0 {
+		return cols
+	}
+	return qs.selects
+}
+
+// getSubquery return the select builder when the QuerySetter is used as a subquery
+func (qs *querySetter) getSubquery() (*sqlbuilder.SelectBuilder, error) {
+	selectNames := qs.selects
+	if len(selectNames) == 0 {
+		selectNames = []string{qs.mi.fields.pk.column}
+	}
+	subQs := *qs
+	subQs.annotations = nil
+	return qs.mi.getSelectBuilder(&subQs, qs.cond, selectNames)
 }
 
 // create new QuerySetter.
@@ -1,6 +1,10 @@
 package orm
 
-import "fmt"
+import (
+	"fmt"
+
+	"github.com/std0d9k81/orm/sqlbuilder"
+)
 
 func quote(field string) string {
 	return fmt.Sprintf("`%v`", field)
@@ -13,3 +17,12 @@ func quoteAll(fields []string) []string {
 	}
 	return quotedFields
 }
+
+// quoteFlavor quote the field in flavor, the sqlbuilder.DefaultFlavor is used if the flavor of builder is not set
+func quoteFlavor(flavor sqlbuilder.Flavor, field string) string {
+	switch flavor {
+	case sqlbuilder.MySQL, sqlbuilder.PostgreSQL, sqlbuilder.SQLite:
+		return flavor.Quote(field)
+	}
+	return sqlbuilder.DefaultFlavor.Quote(field)
+}
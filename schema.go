@@ -0,0 +1,558 @@
+package orm
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/std0d9k81/orm/sqlbuilder"
+)
+
+// StrictBootStrap check the schema of all databases with models by CheckSchema in BootStrap, and panic on drift
+var StrictBootStrap = false
+
+// DriftKind is the kind of schema drift
+type DriftKind string
+
+// the kinds of schema drift
+const (
+	DriftMissingTable  DriftKind = "missing table"
+	DriftMissingShard  DriftKind = "missing shard"
+	DriftMissingColumn DriftKind = "missing column"
+	DriftExtraColumn   DriftKind = "extra column"
+	DriftType          DriftKind = "type mismatch"
+	DriftNullable      DriftKind = "nullability mismatch"
+	DriftMissingIndex  DriftKind = "missing index"
+	DriftMissingUnique DriftKind = "missing unique index"
+)
+
+// SchemaDrift is a difference between the model and the table in database
+type SchemaDrift struct {
+	Kind  DriftKind
+	Model string
+	Table string
+	// Column is empty for the drift of table, and the comma separated columns for the drift of index
+	Column string
+	// Expected and Actual are the column type or nullability of type and nullability mismatch
+	Expected string
+	Actual   string
+}
+
+func (d *SchemaDrift) String() string {
+	s := fmt.Sprintf("table `%s`", d.Table)
+	if d.Column != "" {
+		s += fmt.Sprintf(" column `%s`", d.Column)
+	}
+	s += ": " + string(d.Kind)
+	if d.Expected != "" || d.Actual != "" {
+		s += fmt.Sprintf(", expected %s, actual %s", d.Expected, d.Actual)
+	}
+	return s
+}
+
+// SchemaReport is the result of CheckSchema, drifts are in order of table
+type SchemaReport struct {
+	DBName string
+	Drifts []*SchemaDrift
+}
+
+// HasDrift return true if any drift is found
+func (r *SchemaReport) HasDrift() bool {
+	return len(r.Drifts) > 0
+}
+
+func (r *SchemaReport) String() string {
+	if !r.HasDrift() {
+		return fmt.Sprintf("database %s: no schema drift", r.DBName)
+	}
+	msgs := make([]string, len(r.Drifts))
+	for i, drift := range r.Drifts {
+		msgs[i] = drift.String()
+	}
+	return fmt.Sprintf("database %s: %d schema drifts: %s", r.DBName, len(r.Drifts), strings.Join(msgs, "; "))
+}
+
+// CheckSchema compare the models with the tables in database dbName, report the missing tables, missing shard tables,
+// missing or extra columns, type and nullability mismatches, and the missing indexes.
+// the columns of sharded model are checked on the base table, and the shard tables of shard strategy are checked to exist.
+func CheckSchema(dbName string) (*SchemaReport, error) {
+	BootStrap()
+
+	var (
+		db     = getDB(dbName)
+		flavor = db.flavor()
+		ctx    = context.Background()
+		report = &SchemaReport{DBName: dbName}
+	)
+
+	tables, err := getTables(ctx, db.DB, flavor)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, mi := range getSortedModels("") {
+		if mi.db == dbName {
+			if !tables[mi.table] {
+				report.add(mi, DriftMissingTable, mi.table, "", "", "")
+			} else {
+				columns, err := getColumns(ctx, db.DB, flavor, mi.table)
+				if err != nil {
+					return nil, err
+				}
+				mi.checkColumns(report, flavor, columns)
+
+				indexes, err := getIndexes(ctx, db.DB, flavor, mi.table)
+				if err != nil {
+					return nil, err
+				}
+				mi.checkIndexes(report, indexes)
+			}
+		}
+
+		if !mi.sharded || mi.shardStrategy == nil {
+			continue
+		}
+		for _, suffix := range mi.shardStrategy.Suffixes() {
+			shardDB := mi.getShardDB(suffix)
+			if shardDB == "" {
+				shardDB = mi.db
+			}
+			if table := mi.getTableBySuffix(suffix); shardDB == dbName && !tables[table] {
+				report.add(mi, DriftMissingShard, table, "", "", "")
+			}
+		}
+	}
+
+	return report, nil
+}
+
+// checkSchemaOnBoot check the schema of all databases with models, panic on drift
+func checkSchemaOnBoot() {
+	for _, name := range getModelDBs() {
+		report, err := CheckSchema(name)
+		if err != nil {
+			panic(fmt.Errorf("<orm.BootStrap> check schema of database %s: %v", name, err))
+		}
+		if report.HasDrift() {
+			panic(fmt.Errorf("<orm.BootStrap> %s", report))
+		}
+	}
+}
+
+func (r *SchemaReport) add(mi *modelInfo, kind DriftKind, table, column, expected, actual string) {
+	r.Drifts = append(r.Drifts, &SchemaDrift{
+		Kind:     kind,
+		Model:    mi.fullName,
+		Table:    table,
+		Column:   column,
+		Expected: expected,
+		Actual:   actual,
+	})
+}
+
+// columnInfo is the column of table in database
+type columnInfo struct {
+	name     string
+	typ      string
+	nullable bool
+}
+
+// checkColumns compare the fields of model with the columns of its table, the drifts are added to report
+func (mi *modelInfo) checkColumns(report *SchemaReport, flavor sqlbuilder.Flavor, columns []*columnInfo) {
+	actual := make(map[string]*columnInfo, len(columns))
+	for _, column := range columns {
+		actual[column.name] = column
+	}
+
+	for _, fi := range mi.fields.fieldsDB {
+		column, ok := actual[fi.column]
+		if !ok {
+			report.add(mi, DriftMissingColumn, mi.table, fi.column, "", "")
+			continue
+		}
+		delete(actual, fi.column)
+
+		if expected, ok := fi.checkColumnType(flavor, column.typ); !ok {
+			report.add(mi, DriftType, mi.table, fi.column, expected, column.typ)
+		}
+		if nullable := fi.isNullable(); nullable != column.nullable {
+			report.add(mi, DriftNullable, mi.table, fi.column, nullString(nullable), nullString(column.nullable))
+		}
+	}
+
+	for _, column := range columns {
+		if _, ok := actual[column.name]; ok {
+			report.add(mi, DriftExtraColumn, mi.table, column.name, "", "")
+		}
+	}
+}
+
+// checkColumnType check the column type in database fits the field, return the expected type if not.
+// the exact type is compared only if it's specified by the type, size or digits tag,
+// otherwise the type family of column must fit the go type, e.g. int64 field fits int(10) unsigned column.
+func (fi *fieldInfo) checkColumnType(flavor sqlbuilder.Flavor, actual string) (string, bool) {
+	if fi.dbType != "" || fi.size > 0 || fi.digits > 0 {
+		expected := fi.getColumnType(flavor)
+		return expected, normalizeColumnType(flavor, expected) == normalizeColumnType(flavor, actual)
+	}
+
+	families := fi.getTypeFamilies()
+	if len(families) == 0 {
+		// the custom type without type tag is not checked
+		return "", true
+	}
+	family := getTypeFamily(normalizeColumnType(flavor, actual))
+	for _, f := range families {
+		if f == family {
+			return "", true
+		}
+	}
+	return strings.Join(families, " or ") + " type", false
+}
+
+// the families of column types
+const (
+	familyInteger = "integer"
+	familyFloat   = "float"
+	familyBool    = "boolean"
+	familyString  = "string"
+	familyTime    = "time"
+	familyBinary  = "binary"
+)
+
+// getTypeFamilies return the families of column types which the field fits, empty if unknown
+func (fi *fieldInfo) getTypeFamilies() []string {
+	if fi.json {
+		return []string{familyString}
+	}
+
+	typ := fi.sf.Type
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ == timeType {
+		return []string{familyTime}
+	}
+
+	kind := fi.getKind()
+	switch {
+	case kind == reflect.Bool:
+		return []string{familyBool, familyInteger}
+	case isIntegerKind(kind):
+		return []string{familyInteger}
+	case kind == reflect.Float32 || kind == reflect.Float64:
+		return []string{familyFloat}
+	case kind == reflect.String:
+		return []string{familyString}
+	case kind == reflect.Slice && typ.Elem().Kind() == reflect.Uint8:
+		return []string{familyBinary, familyString}
+	}
+	return nil
+}
+
+// getTypeFamily return the family of normalized column type, empty if unknown
+func getTypeFamily(typ string) string {
+	if i := strings.IndexAny(typ, "( "); i >= 0 {
+		typ = typ[:i]
+	}
+	switch typ {
+	case "tinyint", "smallint", "mediumint", "int", "integer", "bigint", "serial", "bigserial", "smallserial":
+		return familyInteger
+	case "float", "double", "real", "decimal", "numeric", "dec":
+		return familyFloat
+	case "boolean", "bool", "bit":
+		return familyBool
+	case "char", "varchar", "character", "tinytext", "text", "mediumtext", "longtext", "enum", "set",
+		"json", "jsonb", "uuid", "clob", "nchar", "nvarchar":
+		return familyString
+	case "datetime", "timestamp", "date", "time", "year":
+		return familyTime
+	case "binary", "varbinary", "tinyblob", "blob", "mediumblob", "longblob", "bytea":
+		return familyBinary
+	}
+	return ""
+}
+
+// tableIndex is the index of table in database
+type tableIndex struct {
+	name    string
+	unique  bool
+	columns []string
+}
+
+// checkIndexes check the indexes of model exist in the indexes of its table, matched by the columns in order.
+// the unique index of model must be unique in table, the extra indexes of table are not reported.
+func (mi *modelInfo) checkIndexes(report *SchemaReport, indexes []*tableIndex) {
+	for _, index := range mi.indexes {
+		columns := strings.Join(index.columns(), ",")
+
+		found := false
+		for _, actual := range indexes {
+			if strings.Join(actual.columns, ",") == columns && (actual.unique || !index.unique) {
+				found = true
+				break
+			}
+		}
+		if found {
+			continue
+		}
+
+		kind := DriftMissingIndex
+		if index.unique {
+			kind = DriftMissingUnique
+		}
+		report.add(mi, kind, mi.table, columns, "", "")
+	}
+}
+
+func nullString(nullable bool) string {
+	if nullable {
+		return "NULL"
+	}
+	return "NOT NULL"
+}
+
+var (
+	intWidthRegexp  = regexp.MustCompile(`^(tinyint|smallint|mediumint|int|bigint)\(\d+\)`)
+	columnTypeAlias = map[sqlbuilder.Flavor]map[string]string{
+		sqlbuilder.MySQL: {
+			"integer": "int",
+			"boolean": "tinyint",
+			"bool":    "tinyint",
+		},
+		sqlbuilder.PostgreSQL: {
+			"int":         "integer",
+			"int2":        "smallint",
+			"int4":        "integer",
+			"int8":        "bigint",
+			"serial":      "integer",
+			"smallserial": "smallint",
+			"bigserial":   "bigint",
+			"bool":        "boolean",
+			"float4":      "real",
+			"float8":      "double precision",
+			"timestamptz": "timestamp with time zone",
+			"timestamp":   "timestamp without time zone",
+		},
+		sqlbuilder.SQLite: {
+			"int": "integer",
+		},
+	}
+)
+
+// normalizeColumnType return the comparable column type, the display width of MySQL integer and the aliases are removed
+func normalizeColumnType(flavor sqlbuilder.Flavor, typ string) string {
+	typ = strings.Join(strings.Fields(strings.ToLower(typ)), " ")
+	if flavor == sqlbuilder.MySQL {
+		typ = strings.TrimSuffix(typ, " zerofill")
+		typ = intWidthRegexp.ReplaceAllString(typ, "$1")
+	}
+	if alias, ok := columnTypeAlias[flavor][typ]; ok {
+		return alias
+	}
+	return typ
+}
+
+// getTables return the tables in the current schema of database
+func getTables(ctx context.Context, db dbQueryer, flavor sqlbuilder.Flavor) (map[string]bool, error) {
+	var query string
+	switch flavor {
+	case sqlbuilder.PostgreSQL:
+		query = "SELECT table_name FROM information_schema.tables WHERE table_schema = current_schema()"
+	case sqlbuilder.SQLite:
+		query = "SELECT name FROM sqlite_master WHERE type = 'table'"
+	default:
+		query = "SELECT table_name FROM information_schema.tables WHERE table_schema = DATABASE()"
+	}
+
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	// nolint:errcheck
+	defer rows.Close()
+
+	tables := make(map[string]bool)
+	for rows.Next() {
+		var table string
+		if err = rows.Scan(&table); err != nil {
+			return nil, err
+		}
+		tables[table] = true
+	}
+	return tables, rows.Err()
+}
+
+// getColumns return the columns of table in order of position
+func getColumns(ctx context.Context, db dbQueryer, flavor sqlbuilder.Flavor, table string) ([]*columnInfo, error) {
+	if flavor == sqlbuilder.SQLite {
+		return getSQLiteColumns(ctx, db, table)
+	}
+
+	query := "SELECT column_name, column_type, is_nullable FROM information_schema.columns " +
+		"WHERE table_schema = DATABASE() AND table_name = ? ORDER BY ordinal_position"
+	if flavor == sqlbuilder.PostgreSQL {
+		query = "SELECT column_name, CASE WHEN data_type = 'numeric' AND numeric_precision IS NOT NULL " +
+			"THEN 'numeric(' || numeric_precision || ',' || numeric_scale || ')' " +
+			"WHEN character_maximum_length IS NULL THEN data_type " +
+			"WHEN data_type = 'character varying' THEN 'varchar(' || character_maximum_length || ')' " +
+			"ELSE data_type || '(' || character_maximum_length || ')' END, is_nullable " +
+			"FROM information_schema.columns WHERE table_schema = current_schema() AND table_name = $1 ORDER BY ordinal_position"
+	}
+
+	rows, err := db.QueryContext(ctx, query, table)
+	if err != nil {
+		return nil, err
+	}
+	// nolint:errcheck
+	defer rows.Close()
+
+	var columns []*columnInfo
+	for rows.Next() {
+		var (
+			column   = &columnInfo{}
+			nullable string
+		)
+		if err = rows.Scan(&column.name, &column.typ, &nullable); err != nil {
+			return nil, err
+		}
+		column.nullable = nullable == "YES"
+		columns = append(columns, column)
+	}
+	return columns, rows.Err()
+}
+
+// getSQLiteColumns return the columns of SQLite table by PRAGMA table_info
+func getSQLiteColumns(ctx context.Context, db dbQueryer, table string) ([]*columnInfo, error) {
+	rows, err := db.QueryContext(ctx, fmt.Sprintf("PRAGMA table_info(%s)", sqlbuilder.SQLite.Quote(table)))
+	if err != nil {
+		return nil, err
+	}
+	// nolint:errcheck
+	defer rows.Close()
+
+	var columns []*columnInfo
+	for rows.Next() {
+		var (
+			column      = &columnInfo{}
+			cid         int
+			notNull, pk int
+			initial     interface{}
+		)
+		if err = rows.Scan(&cid, &column.name, &column.typ, &notNull, &initial, &pk); err != nil {
+			return nil, err
+		}
+		column.nullable = notNull == 0 && pk == 0
+		columns = append(columns, column)
+	}
+	return columns, rows.Err()
+}
+
+// getIndexes return the indexes of table, including primary key
+func getIndexes(ctx context.Context, db dbQueryer, flavor sqlbuilder.Flavor, table string) ([]*tableIndex, error) {
+	if flavor == sqlbuilder.SQLite {
+		return getSQLiteIndexes(ctx, db, table)
+	}
+
+	query := "SELECT index_name, non_unique = 0, column_name FROM information_schema.statistics " +
+		"WHERE table_schema = DATABASE() AND table_name = ? ORDER BY index_name, seq_in_index"
+	if flavor == sqlbuilder.PostgreSQL {
+		query = "SELECT i.relname, ix.indisunique, a.attname FROM pg_index ix " +
+			"JOIN pg_class t ON t.oid = ix.indrelid JOIN pg_class i ON i.oid = ix.indexrelid " +
+			"JOIN LATERAL unnest(ix.indkey) WITH ORDINALITY AS k(attnum, ord) ON true " +
+			"JOIN pg_attribute a ON a.attrelid = t.oid AND a.attnum = k.attnum " +
+			"WHERE t.relname = $1 AND t.relnamespace = current_schema()::regnamespace ORDER BY i.relname, k.ord"
+	}
+
+	rows, err := db.QueryContext(ctx, query, table)
+	if err != nil {
+		return nil, err
+	}
+	// nolint:errcheck
+	defer rows.Close()
+
+	var indexes []*tableIndex
+	for rows.Next() {
+		var (
+			name, column string
+			unique       bool
+		)
+		if err = rows.Scan(&name, &unique, &column); err != nil {
+			return nil, err
+		}
+		if n := len(indexes); n == 0 || indexes[n-1].name != name {
+			indexes = append(indexes, &tableIndex{name: name, unique: unique})
+		}
+		last := indexes[len(indexes)-1]
+		last.columns = append(last.columns, column)
+	}
+	return indexes, rows.Err()
+}
+
+// getSQLiteIndexes return the indexes of SQLite table by PRAGMA index_list and index_info
+func getSQLiteIndexes(ctx context.Context, db dbQueryer, table string) ([]*tableIndex, error) {
+	indexes, err := func() ([]*tableIndex, error) {
+		rows, err := db.QueryContext(ctx, fmt.Sprintf("PRAGMA index_list(%s)", sqlbuilder.SQLite.Quote(table)))
+		if err != nil {
+			return nil, err
+		}
+		// nolint:errcheck
+		defer rows.Close()
+
+		columns, err := rows.Columns()
+		if err != nil {
+			return nil, err
+		}
+
+		var indexes []*tableIndex
+		for rows.Next() {
+			// the columns of index_list are seq, name, unique, origin and partial in newer versions
+			var (
+				index  = &tableIndex{}
+				seq    int
+				unique int
+				dest   = []interface{}{&seq, &index.name, &unique}
+			)
+			for range columns[len(dest):] {
+				dest = append(dest, new(interface{}))
+			}
+			if err = rows.Scan(dest...); err != nil {
+				return nil, err
+			}
+			index.unique = unique != 0
+			indexes = append(indexes, index)
+		}
+		return indexes, rows.Err()
+	}()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, index := range indexes {
+		if err = func() error {
+			rows, err := db.QueryContext(ctx, fmt.Sprintf("PRAGMA index_info(%s)", sqlbuilder.SQLite.Quote(index.name)))
+			if err != nil {
+				return err
+			}
+			// nolint:errcheck
+			defer rows.Close()
+
+			for rows.Next() {
+				var (
+					seqno, cid int
+					column     string
+				)
+				if err = rows.Scan(&seqno, &cid, &column); err != nil {
+					return err
+				}
+				index.columns = append(index.columns, column)
+			}
+			return rows.Err()
+		}(); err != nil {
+			return nil, err
+		}
+	}
+	return indexes, nil
+}
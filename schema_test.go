@@ -0,0 +1,185 @@
+package orm
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/std0d9k81/orm/sqlbuilder"
+	"github.com/stretchr/testify/require"
+)
+
+type schemaModel struct {
+	ID      int64   `orm:"column(id);pk;auto"`
+	Name    string  `orm:"size(64)"`
+	Nick    *string `orm:"size(32)"`
+	Age     int8
+	Enabled bool
+}
+
+func TestNormalizeColumnType(t *testing.T) {
+	for typ, expected := range map[string]string{
+		"bigint(20)":           "bigint",
+		"INT(10) UNSIGNED":     "int unsigned",
+		"tinyint(1)":           "tinyint",
+		"int(11) zerofill":     "int",
+		"integer":              "int",
+		"varchar(64)":          "varchar(64)",
+		"double":               "double",
+		"datetime":             "datetime",
+		"decimal(10,2)":        "decimal(10,2)",
+		"bigint  unsigned":     "bigint unsigned",
+		"timestamp":            "timestamp",
+		"longtext":             "longtext",
+		"mediumint(8)":         "mediumint",
+		"bool":                 "tinyint",
+		"smallint(5) unsigned": "smallint unsigned",
+	} {
+		require.Equal(t, expected, normalizeColumnType(sqlbuilder.MySQL, typ), typ)
+	}
+
+	require.Equal(t, "bigint", normalizeColumnType(sqlbuilder.PostgreSQL, "bigserial"))
+	require.Equal(t, "timestamp with time zone", normalizeColumnType(sqlbuilder.PostgreSQL, "TIMESTAMPTZ"))
+	require.Equal(t, "bigint(20)", normalizeColumnType(sqlbuilder.PostgreSQL, "bigint(20)"), "width only removed for MySQL")
+	require.Equal(t, "integer", normalizeColumnType(sqlbuilder.SQLite, "INT"))
+}
+
+func TestCheckColumns(t *testing.T) {
+	mi := newModelInfo(reflect.ValueOf(&schemaModel{}))
+	mi.table = "schema_model"
+
+	report := &SchemaReport{DBName: "default"}
+	mi.checkColumns(report, sqlbuilder.MySQL, []*columnInfo{
+		{name: "id", typ: "bigint(20)"},
+		{name: "name", typ: "varchar(64)"},
+		{name: "nick", typ: "varchar(32)", nullable: true},
+		{name: "age", typ: "tinyint(4)"},
+		{name: "enabled", typ: "tinyint(1)"},
+	})
+	require.False(t, report.HasDrift(), report.String())
+
+	report = &SchemaReport{DBName: "default"}
+	mi.checkColumns(report, sqlbuilder.MySQL, []*columnInfo{
+		{name: "id", typ: "bigint(20)"},
+		{name: "name", typ: "varchar(32)"},
+		{name: "nick", typ: "varchar(32)"},
+		{name: "enabled", typ: "tinyint(1)"},
+		{name: "deleted", typ: "tinyint(1)"},
+	})
+	require.Equal(t, []*SchemaDrift{
+		{Kind: DriftType, Model: mi.fullName, Table: "schema_model", Column: "name", Expected: "varchar(64)", Actual: "varchar(32)"},
+		{Kind: DriftNullable, Model: mi.fullName, Table: "schema_model", Column: "nick", Expected: "NULL", Actual: "NOT NULL"},
+		{Kind: DriftMissingColumn, Model: mi.fullName, Table: "schema_model", Column: "age"},
+		{Kind: DriftExtraColumn, Model: mi.fullName, Table: "schema_model", Column: "deleted"},
+	}, report.Drifts)
+	require.Equal(t, "database default: 4 schema drifts: "+
+		"table `schema_model` column `name`: type mismatch, expected varchar(64), actual varchar(32); "+
+		"table `schema_model` column `nick`: nullability mismatch, expected NULL, actual NOT NULL; "+
+		"table `schema_model` column `age`: missing column; "+
+		"table `schema_model` column `deleted`: extra column", report.String())
+
+	report = &SchemaReport{DBName: "default"}
+	mi.checkColumns(report, sqlbuilder.SQLite, []*columnInfo{
+		{name: "id", typ: "integer"},
+		{name: "name", typ: "varchar(64)"},
+		{name: "nick", typ: "varchar(32)", nullable: true},
+		{name: "age", typ: "INTEGER"},
+		{name: "enabled", typ: "integer"},
+	})
+	require.False(t, report.HasDrift(), report.String())
+}
+
+func TestCheckColumnFamilies(t *testing.T) {
+	// the column types of tests/db.sql reported by MySQL
+	tables := map[interface{}][]*columnInfo{
+		&shardedPerson{}: {
+			{name: "id", typ: "int(10) unsigned"},
+			{name: "person_id", typ: "int(10) unsigned"},
+			{name: "name", typ: "varchar(255)"},
+			{name: "age", typ: "int(11)"},
+		},
+		&anyObj{}: {
+			{name: "id", typ: "int(10) unsigned"},
+			{name: "obj_omit", typ: "text"},
+			{name: "obj", typ: "text"},
+		},
+		&timeObj{}: {
+			{name: "id", typ: "int(10) unsigned"},
+			{name: "obj_time", typ: "timestamp"},
+		},
+		&jsonModel{}: {
+			{name: "id", typ: "int(10) unsigned"},
+			{name: "content", typ: "varchar(1024)"},
+			{name: "content_ptr", typ: "varchar(1024)"},
+		},
+		&dynamicModel{}: {
+			{name: "id", typ: "int(10) unsigned"},
+			{name: "type", typ: "varchar(4)"},
+			{name: "content", typ: "text"},
+		},
+	}
+	for md, columns := range tables {
+		mi := newModelInfo(reflect.ValueOf(md))
+		report := &SchemaReport{DBName: "default"}
+		mi.checkColumns(report, sqlbuilder.MySQL, columns)
+		require.False(t, report.HasDrift(), report.String())
+	}
+
+	// json_test2 is created like json_test, content_ptr is not in the model
+	mi := newModelInfo(reflect.ValueOf(&mapJsonModel{}))
+	report := &SchemaReport{DBName: "default"}
+	mi.checkColumns(report, sqlbuilder.MySQL, []*columnInfo{
+		{name: "id", typ: "int(10) unsigned"},
+		{name: "content", typ: "varchar(1024)"},
+		{name: "content_ptr", typ: "varchar(1024)"},
+	})
+	require.Len(t, report.Drifts, 1)
+	require.Equal(t, DriftExtraColumn, report.Drifts[0].Kind)
+
+	mi = newModelInfo(reflect.ValueOf(&timeObj{}))
+	mi.table = "time_obj"
+	report = &SchemaReport{DBName: "default"}
+	mi.checkColumns(report, sqlbuilder.MySQL, []*columnInfo{
+		{name: "id", typ: "varchar(10)"},
+		{name: "obj_time", typ: "bigint(20)"},
+	})
+	require.Equal(t, []*SchemaDrift{
+		{Kind: DriftType, Model: mi.fullName, Table: "time_obj", Column: "id", Expected: "integer type", Actual: "varchar(10)"},
+		{Kind: DriftType, Model: mi.fullName, Table: "time_obj", Column: "obj_time", Expected: "time type", Actual: "bigint(20)"},
+	}, report.Drifts)
+
+	mi = newModelInfo(reflect.ValueOf(&schemaModel{}))
+	report = &SchemaReport{DBName: "default"}
+	mi.checkColumns(report, sqlbuilder.PostgreSQL, []*columnInfo{
+		{name: "id", typ: "bigint"},
+		{name: "name", typ: "varchar(64)"},
+		{name: "nick", typ: "varchar(32)", nullable: true},
+		{name: "age", typ: "integer"},
+		{name: "enabled", typ: "boolean"},
+	})
+	require.False(t, report.HasDrift(), report.String())
+}
+
+func TestCheckIndexes(t *testing.T) {
+	mi := newModelInfo(reflect.ValueOf(&indexModel{}))
+	mi.table = "index_model"
+
+	report := &SchemaReport{DBName: "default"}
+	mi.checkIndexes(report, []*tableIndex{
+		{name: "PRIMARY", unique: true, columns: []string{"id"}},
+		{name: "idx_status", columns: []string{"status"}},
+		{name: "uniq_code", unique: true, columns: []string{"code", "user_id"}},
+		{name: "idx_user", columns: []string{"user_id", "created"}},
+	})
+	require.False(t, report.HasDrift(), report.String())
+
+	report = &SchemaReport{DBName: "default"}
+	mi.checkIndexes(report, []*tableIndex{
+		{name: "idx_status", unique: true, columns: []string{"status"}},
+		{name: "idx_code", columns: []string{"code", "user_id"}},
+		{name: "idx_user", columns: []string{"created", "user_id"}},
+	})
+	require.Equal(t, []*SchemaDrift{
+		{Kind: DriftMissingUnique, Model: mi.fullName, Table: "index_model", Column: "code,user_id"},
+		{Kind: DriftMissingIndex, Model: mi.fullName, Table: "index_model", Column: "user_id,created"},
+	}, report.Drifts)
+}
@@ -0,0 +1,155 @@
+package orm
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/std0d9k81/orm/sqlbuilder"
+)
+
+// SearchMode is the mode of full-text search
+type SearchMode int
+
+// define full-text search modes
+const (
+	// SearchNatural is the natural language mode, it's the default mode
+	SearchNatural SearchMode = iota
+	// SearchBoolean is the boolean mode, the query can have operators, e.g. "+golang -java"
+	SearchBoolean
+	// SearchExpansion is the natural language mode with query expansion, it's the natural mode except MySQL
+	SearchExpansion
+)
+
+// Expr is the expression can be annotated to QuerySetter, e.g. F("Qty").Mul(F("Price")), Match("Title").Against("orm")
+type Expr interface {
+	build(mi *modelInfo, flavor sqlbuilder.Flavor) sqlbuilder.Builder
+}
+
+var (
+	_ Expr = new(FExpr)
+	_ Expr = new(SearchExpr)
+)
+
+// annotation of QuerySetter, the expr is selected as alias
+type annotation struct {
+	alias string
+	expr  Expr
+}
+
+// isAnnotation check the name is alias of annotations or not
+func isAnnotation(annotations []annotation, name string) bool {
+	for _, a := range annotations {
+		if a.alias == name {
+			return true
+		}
+	}
+	return false
+}
+
+// SearchExpr is the full-text search expression of fields
+type SearchExpr struct {
+	fields []string
+	query  string
+	mode   SearchMode
+}
+
+// Match return the full-text search expression of fields, the fields should have a full-text index. usage:
+//	qs.Filter("__search", orm.Match("Title", "Body").Against("+golang -java", orm.SearchBoolean))
+//	qs.Annotate("score", orm.Match("Title", "Body").Against("golang")).OrderBy("-score")
+func Match(fields ...string) *SearchExpr {
+	if len(fields) == 0 {
+		panic(fmt.Errorf("orm.Match need at least one field"))
+	}
+	return &SearchExpr{fields: fields}
+}
+
+// Against set the query of full-text search, mode is SearchNatural if not specified
+func (e SearchExpr) Against(query string, mode ...SearchMode) *SearchExpr {
+	e.query = query
+	e.mode = SearchNatural
+	if len(mode) > 0 {
+		e.mode = mode[0]
+	}
+	return &e
+}
+
+// columns return the columns of fields quoted in flavor
+func (e *SearchExpr) columns(mi *modelInfo, flavor sqlbuilder.Flavor) []string {
+	columns := make([]string, len(e.fields))
+	for i, name := range e.fields {
+		fi, ok := mi.fields.GetByAny(name)
+		if !ok {
+			panic(fmt.Errorf("wrong db field/column name `%s` for model `%s`", name, mi.fullName))
+		}
+		columns[i] = quoteFlavor(flavor, fi.column)
+	}
+	return columns
+}
+
+// build return the builder of relevance score, the higher the more relevant.
+func (e *SearchExpr) build(mi *modelInfo, flavor sqlbuilder.Flavor) sqlbuilder.Builder {
+	columns := e.columns(mi, flavor)
+
+	switch flavor {
+	case sqlbuilder.PostgreSQL:
+		return sqlbuilder.Buildf("ts_rank("+e.tsVector(columns)+", "+e.tsQuery()+")", e.query)
+	case sqlbuilder.SQLite:
+		// rank of FTS5 is the negative bm25 score, the lower the more relevant
+		return sqlbuilder.Buildf("(-rank)")
+	default:
+		return e.match(columns)
+	}
+}
+
+// predicate return the builder of condition that fields match the query
+func (e *SearchExpr) predicate(mi *modelInfo, flavor sqlbuilder.Flavor) sqlbuilder.Builder {
+	columns := e.columns(mi, flavor)
+
+	switch flavor {
+	case sqlbuilder.PostgreSQL:
+		return sqlbuilder.Buildf(e.tsVector(columns)+" @@ "+e.tsQuery(), e.query)
+	case sqlbuilder.SQLite:
+		if len(columns) == 1 {
+			return sqlbuilder.Buildf(columns[0]+" MATCH %v", e.query)
+		}
+		exprs := make([]string, len(columns))
+		args := make([]interface{}, len(columns))
+		for i, column := range columns {
+			exprs[i] = column + " MATCH %v"
+			args[i] = e.query
+		}
+		return sqlbuilder.Buildf("("+strings.Join(exprs, " OR ")+")", args...)
+	default:
+		return e.match(columns)
+	}
+}
+
+// match return MATCH ... AGAINST of MySQL
+func (e *SearchExpr) match(columns []string) sqlbuilder.Builder {
+	var mode string
+	switch e.mode {
+	case SearchBoolean:
+		mode = " IN BOOLEAN MODE"
+	case SearchExpansion:
+		mode = " WITH QUERY EXPANSION"
+	default:
+		mode = " IN NATURAL LANGUAGE MODE"
+	}
+	return sqlbuilder.Buildf("MATCH("+strings.Join(columns, ", ")+") AGAINST(%v"+mode+")", e.query)
+}
+
+// tsVector return to_tsvector of PostgreSQL
+func (e *SearchExpr) tsVector(columns []string) string {
+	if len(columns) == 1 {
+		return "to_tsvector(" + columns[0] + ")"
+	}
+	return "to_tsvector(concat_ws(' ', " + strings.Join(columns, ", ") + "))"
+}
+
+// tsQuery return the format of tsquery of PostgreSQL
+func (e *SearchExpr) tsQuery() string {
+	if e.mode == SearchBoolean {
+		return "to_tsquery(%v)"
+	}
+	return "plainto_tsquery(%v)"
+}
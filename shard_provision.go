@@ -0,0 +1,302 @@
+package orm
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/std0d9k81/kate/log/ctxzap"
+	"github.com/std0d9k81/orm/sqlbuilder"
+	"go.uber.org/zap"
+)
+
+// ProvisionOption is the option of ProvisionShards
+type ProvisionOption func(*provisionOptions)
+
+type provisionOptions struct {
+	suffixes  []string
+	ahead     int
+	retention int
+	drop      bool
+	dryRun    io.Writer
+}
+
+// ProvisionSuffixes create the shards with suffixes, instead of the suffixes of shard strategy.
+// it's required for the model sharded by TableSuffix method.
+func ProvisionSuffixes(suffixes ...string) ProvisionOption {
+	return func(opts *provisionOptions) {
+		opts.suffixes = suffixes
+	}
+}
+
+// ProvisionAhead pre-create the shards of n future periods for the model sharded by ByTime
+func ProvisionAhead(n int) ProvisionOption {
+	return func(opts *provisionOptions) {
+		opts.ahead = n
+	}
+}
+
+// ProvisionRetention keep the shards of recent n periods for the model sharded by ByTime,
+// the shards before them are listed as expired, and dropped if drop is true.
+func ProvisionRetention(n int, drop bool) ProvisionOption {
+	return func(opts *provisionOptions) {
+		opts.retention = n
+		opts.drop = drop
+	}
+}
+
+// ProvisionDryRun print the DDL to w instead of executing, the result lists the shards would be created and dropped
+func ProvisionDryRun(w io.Writer) ProvisionOption {
+	return func(opts *provisionOptions) {
+		opts.dryRun = w
+	}
+}
+
+// ShardProvision is the result of ProvisionShards, the suffixes of shards are in order
+type ShardProvision struct {
+	// Created the shards created
+	Created []string
+	// Expired the shards out of the retention window
+	Expired []string
+	// Dropped the expired shards dropped
+	Dropped []string
+}
+
+// ProvisionShards create the missing shard tables of the sharded model md, it's idempotent and safe to run at startup.
+// the shards of strategy are created, the future periods of ByTime are pre-created by ProvisionAhead,
+// and the shards out of ProvisionRetention are listed or dropped.
+// for example:
+//	result, err := orm.ProvisionShards(new(Log), orm.ProvisionAhead(2), orm.ProvisionRetention(12, true))
+func ProvisionShards(md interface{}, options ...ProvisionOption) (*ShardProvision, error) {
+	BootStrap()
+
+	typ := reflect.Indirect(reflect.ValueOf(md)).Type()
+	mi, ok := modelCache.get(getFullName(typ))
+	if !ok {
+		panic(fmt.Errorf("<ProvisionShards> model `%s` not registered", getFullName(typ)))
+	}
+	return provisionShards(mi, options)
+}
+
+// shardDB is the database of shards, the DDL is in its flavor
+type shardDB struct {
+	name   string
+	db     dbQueryer
+	flavor sqlbuilder.Flavor
+}
+
+// provisionShards create the missing shard tables of the sharded model, and list or drop the expired shards.
+// the shards are created by "CREATE TABLE ... LIKE" the base table if it exists in the database of shards,
+// otherwise by the DDL of model, e.g. the databases of ShardDBs have only the shard tables.
+// the DDL is in the flavor of each database, and not executed in the transaction of caller as MySQL commits it implicitly.
+// nolint:gocyclo
+func provisionShards(mi *modelInfo, options []ProvisionOption) (*ShardProvision, error) {
+	if !mi.sharded {
+		panic(fmt.Errorf("<ProvisionShards> model `%s` is not sharded", mi.fullName))
+	}
+
+	opts := &provisionOptions{}
+	for _, option := range options {
+		option(opts)
+	}
+
+	ts := mi.getTimeStrategy()
+	suffixes := opts.suffixes
+	if len(suffixes) == 0 {
+		switch {
+		case ts != nil:
+			suffixes = ts.suffixesBetween(ts.start, ts.period.add(time.Now(), opts.ahead))
+			if opts.retention > 0 {
+				if earliest := ts.period.add(time.Now(), 1-opts.retention); earliest.After(ts.start) {
+					suffixes = ts.suffixesBetween(earliest, ts.period.add(time.Now(), opts.ahead))
+				}
+			}
+		case mi.shardStrategy != nil:
+			suffixes = mi.shardStrategy.Suffixes()
+		default:
+			panic(fmt.Errorf("<ProvisionShards> suffixes cannot empty for model `%s` without shard strategy", mi.fullName))
+		}
+	}
+	if opts.retention > 0 && ts == nil {
+		panic(fmt.Errorf("<ProvisionShards> retention is only supported by model sharded by time"))
+	}
+
+	var (
+		ctx              = ctxzap.ToContext(context.Background(), defaultLogger)
+		dbNames, dbShard = mi.groupShardsByDB(suffixes)
+		result           = &ShardProvision{}
+		expired          = make(map[string][]*shardDB)
+	)
+	for _, dbName := range dbNames {
+		sdb := &shardDB{name: dbName, db: getDB(dbName).DB, flavor: getDB(dbName).flavor()}
+		if Debug {
+			sdb.db = newDbQueryLog(ctx, dbName, sdb.db)
+		}
+
+		existing, err := mi.getExistingShards(ctx, sdb)
+		if err != nil {
+			return result, fmt.Errorf("list shard tables in database %s: %v", dbName, err)
+		}
+
+		var hasBase bool
+		for _, suffix := range dbShard[dbName] {
+			if !existing[suffix] {
+				if hasBase, err = tableExists(ctx, sdb.db, sdb.flavor, mi.table); err != nil {
+					return result, fmt.Errorf("check base table %s in database %s: %v", mi.table, dbName, err)
+				}
+				break
+			}
+		}
+
+		for _, suffix := range dbShard[dbName] {
+			if existing[suffix] {
+				continue
+			}
+			for _, query := range mi.getCreateShardSQL(sdb.flavor, suffix, hasBase) {
+				if err = opts.exec(ctx, sdb.db, "create shard table", query); err != nil {
+					return result, fmt.Errorf("create shard table %s in database %s: %v", mi.getTableBySuffix(suffix), dbName, err)
+				}
+			}
+			result.Created = append(result.Created, suffix)
+		}
+
+		if opts.retention <= 0 {
+			continue
+		}
+
+		earliest := ts.period.add(time.Now(), 1-opts.retention)
+		for suffix := range existing {
+			if t, ok := ts.parseSuffix(suffix); ok && t.Before(earliest) {
+				if len(expired[suffix]) == 0 {
+					result.Expired = append(result.Expired, suffix)
+				}
+				expired[suffix] = append(expired[suffix], sdb)
+			}
+		}
+	}
+
+	sort.Strings(result.Expired)
+	if opts.drop {
+		for _, suffix := range result.Expired {
+			for _, sdb := range expired[suffix] {
+				query := fmt.Sprintf("DROP TABLE IF EXISTS %s", sdb.flavor.Quote(mi.getTableBySuffix(suffix)))
+				if err := opts.exec(ctx, sdb.db, "drop shard table", query); err != nil {
+					return result, fmt.Errorf("drop shard table %s in database %s: %v", mi.getTableBySuffix(suffix), sdb.name, err)
+				}
+			}
+			result.Dropped = append(result.Dropped, suffix)
+		}
+	}
+
+	return result, nil
+}
+
+// groupShardsByDB return the databases of shards in order, and the suffixes of shards in each database.
+// all the databases of ShardDBs are returned, as the expired shards may be in the database without current shards.
+func (mi *modelInfo) groupShardsByDB(suffixes []string) ([]string, map[string][]string) {
+	var (
+		dbNames  []string
+		dbShards = make(map[string][]string)
+	)
+	addDB := func(dbName string) string {
+		if dbName == "" {
+			dbName = mi.db
+		}
+		if _, ok := dbShards[dbName]; !ok {
+			dbNames = append(dbNames, dbName)
+			dbShards[dbName] = nil
+		}
+		return dbName
+	}
+
+	for _, suffix := range suffixes {
+		dbName := addDB(mi.getShardDB(suffix))
+		dbShards[dbName] = append(dbShards[dbName], suffix)
+	}
+	if s, ok := mi.shardStrategy.(*dbShardStrategy); ok {
+		var names []string
+		for _, dbName := range s.dbs {
+			names = append(names, dbName)
+		}
+		sort.Strings(names)
+		for _, dbName := range names {
+			addDB(dbName)
+		}
+	}
+	return dbNames, dbShards
+}
+
+// getCreateShardSQL return the DDL creating the shard table in flavor, LIKE the base table if hasBase, otherwise by the DDL of model.
+// SQLite doesn't support LIKE, the shards are always created by the DDL of model.
+func (mi *modelInfo) getCreateShardSQL(flavor sqlbuilder.Flavor, suffix string, hasBase bool) []string {
+	table := mi.getTableBySuffix(suffix)
+	if hasBase {
+		switch flavor {
+		case sqlbuilder.MySQL:
+			return []string{fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s LIKE %s", flavor.Quote(table), flavor.Quote(mi.table))}
+		case sqlbuilder.PostgreSQL:
+			return []string{fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (LIKE %s INCLUDING ALL)", flavor.Quote(table), flavor.Quote(mi.table))}
+		}
+	}
+	return mi.getCreateTableSQLOf(flavor, table)
+}
+
+// getTimeStrategy return the time strategy of model, nil if not sharded by time
+func (mi *modelInfo) getTimeStrategy() *timeStrategy {
+	switch s := mi.shardStrategy.(type) {
+	case *timeStrategy:
+		return s
+	case *dbShardStrategy:
+		ts, _ := s.ShardStrategy.(*timeStrategy)
+		return ts
+	}
+	return nil
+}
+
+// getExistingShards return the suffixes of existing shard tables in the database
+func (mi *modelInfo) getExistingShards(ctx context.Context, sdb *shardDB) (map[string]bool, error) {
+	var query string
+	switch sdb.flavor {
+	case sqlbuilder.PostgreSQL:
+		query = "SELECT table_name FROM information_schema.tables WHERE table_schema = current_schema() AND table_name LIKE $1"
+	case sqlbuilder.SQLite:
+		query = "SELECT name FROM sqlite_master WHERE type = 'table' AND name LIKE ? ESCAPE '\\'"
+	default:
+		query = "SELECT table_name FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name LIKE ?"
+	}
+
+	prefix := mi.table + "_"
+	pattern := strings.NewReplacer(`\`, `\\`, "_", `\_`, "%", `\%`).Replace(prefix) + "%"
+
+	rows, err := sdb.db.QueryContext(ctx, query, pattern)
+	if err != nil {
+		return nil, err
+	}
+	// nolint:errcheck
+	defer rows.Close()
+
+	shards := make(map[string]bool)
+	for rows.Next() {
+		var table string
+		if err = rows.Scan(&table); err != nil {
+			return nil, err
+		}
+		shards[strings.TrimPrefix(table, prefix)] = true
+	}
+	return shards, rows.Err()
+}
+
+// exec execute the DDL of shard, or print it in dry run
+func (opts *provisionOptions) exec(ctx context.Context, db dbQueryer, msg, query string) error {
+	if opts.dryRun != nil {
+		_, err := fmt.Fprintf(opts.dryRun, "%s;\n", query)
+		return err
+	}
+	ctxzap.Extract(ctx).With(defaultLoggerTag).Info(msg, zap.String("query", query))
+	_, err := db.ExecContext(ctx, query)
+	return err
+}
@@ -0,0 +1,454 @@
+package orm
+
+import (
+	"fmt"
+	"hash/crc32"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// ShardStrategy compute the table suffix from the value of shard key field.
+type ShardStrategy interface {
+	// Suffix return the table suffix of the shard key value
+	Suffix(value interface{}) (string, error)
+	// Suffixes return all the table suffixes in order
+	Suffixes() []string
+}
+
+// DBShardStrategy is the ShardStrategy also routes the sharded tables to the registered databases.
+type DBShardStrategy interface {
+	ShardStrategy
+	// DB return the registered database name of the table suffix
+	DB(suffix string) string
+}
+
+var (
+	_ ShardStrategy   = new(hashModStrategy)
+	_ ShardStrategy   = new(rangeStrategy)
+	_ ShardStrategy   = new(lookupStrategy)
+	_ ShardStrategy   = new(timeStrategy)
+	_ DBShardStrategy = new(dbShardStrategy)
+)
+
+// hashModStrategy shard by the hash of value mod shards
+type hashModStrategy struct {
+	shards int
+}
+
+// HashMod return the strategy that shards the table into n tables with suffix "0" ~ "n-1".
+// integer value is sharded by value % n, others by crc32(value) % n.
+func HashMod(n int) ShardStrategy {
+	if n <= 0 {
+		panic(fmt.Errorf("orm.HashMod shards must be positive"))
+	}
+	return &hashModStrategy{shards: n}
+}
+
+func (s *hashModStrategy) Suffix(value interface{}) (string, error) {
+	var index int64
+	if v, ok := toInt64(value); ok {
+		index = v % int64(s.shards)
+		if index < 0 {
+			index += int64(s.shards)
+		}
+	} else {
+		index = int64(crc32.ChecksumIEEE([]byte(fmt.Sprint(value))) % uint32(s.shards))
+	}
+	return strconv.FormatInt(index, 10), nil
+}
+
+func (s *hashModStrategy) Suffixes() []string {
+	return ShardSuffixes(s.shards)
+}
+
+// ShardRange is the range [Start, End) of shard key value stored in table with Suffix
+type ShardRange struct {
+	Start  int64
+	End    int64
+	Suffix string
+}
+
+// rangeStrategy shard by the range of integer value
+type rangeStrategy struct {
+	ranges []ShardRange
+}
+
+// Range return the strategy that shards the table by the ranges of integer value.
+// for example:
+//	orm.Range(
+//		orm.ShardRange{Start: 0, End: 1000000, Suffix: "0"},
+//		orm.ShardRange{Start: 1000000, End: 2000000, Suffix: "1"},
+//	)
+func Range(ranges ...ShardRange) ShardStrategy {
+	if len(ranges) == 0 {
+		panic(fmt.Errorf("orm.Range need at least one range"))
+	}
+	sorted := make([]ShardRange, len(ranges))
+	copy(sorted, ranges)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Start < sorted[j].Start })
+	for i, r := range sorted {
+		if r.Start >= r.End || r.Suffix == "" {
+			panic(fmt.Errorf("orm.Range wrong range [%d, %d) of suffix `%s`", r.Start, r.End, r.Suffix))
+		}
+		if i > 0 && r.Start < sorted[i-1].End {
+			panic(fmt.Errorf("orm.Range range [%d, %d) overlaps [%d, %d)", r.Start, r.End, sorted[i-1].Start, sorted[i-1].End))
+		}
+	}
+	return &rangeStrategy{ranges: sorted}
+}
+
+func (s *rangeStrategy) Suffix(value interface{}) (string, error) {
+	v, ok := toInt64(value)
+	if !ok {
+		return "", fmt.Errorf("shard key value %v is not integer", value)
+	}
+	i := sort.Search(len(s.ranges), func(i int) bool { return s.ranges[i].End > v })
+	if i == len(s.ranges) || s.ranges[i].Start > v {
+		return "", fmt.Errorf("shard key value %d out of ranges", v)
+	}
+	return s.ranges[i].Suffix, nil
+}
+
+func (s *rangeStrategy) Suffixes() []string {
+	return uniqueSuffixes(len(s.ranges), func(i int) string { return s.ranges[i].Suffix })
+}
+
+// lookupStrategy shard by the lookup table
+type lookupStrategy struct {
+	table map[string]string
+	keys  []string
+}
+
+// Lookup return the strategy that shards the table by the lookup table from value to suffix,
+// the value is formatted by fmt.Sprint as the key, e.g. orm.Lookup(map[string]string{"cn": "asia", "jp": "asia", "us": "america"})
+func Lookup(table map[string]string) ShardStrategy {
+	if len(table) == 0 {
+		panic(fmt.Errorf("orm.Lookup table cannot empty"))
+	}
+	s := &lookupStrategy{table: make(map[string]string, len(table))}
+	for key, suffix := range table {
+		if suffix == "" {
+			panic(fmt.Errorf("orm.Lookup suffix of `%s` cannot empty", key))
+		}
+		s.table[key] = suffix
+		s.keys = append(s.keys, key)
+	}
+	sort.Strings(s.keys)
+	return s
+}
+
+func (s *lookupStrategy) Suffix(value interface{}) (string, error) {
+	suffix, ok := s.table[fmt.Sprint(value)]
+	if !ok {
+		return "", fmt.Errorf("shard key value %v not found in lookup table", value)
+	}
+	return suffix, nil
+}
+
+func (s *lookupStrategy) Suffixes() []string {
+	return uniqueSuffixes(len(s.keys), func(i int) string { return s.table[s.keys[i]] })
+}
+
+// ShardPeriod is the period of time-based shards
+type ShardPeriod int
+
+// define periods of time-based shards
+const (
+	// ShardDaily shards by day, the suffix is like "20060102"
+	ShardDaily ShardPeriod = iota
+	// ShardMonthly shards by month, the suffix is like "200601"
+	ShardMonthly
+	// ShardYearly shards by year, the suffix is like "2006"
+	ShardYearly
+)
+
+// layout return the time layout of suffix
+func (p ShardPeriod) layout() string {
+	switch p {
+	case ShardDaily:
+		return "20060102"
+	case ShardMonthly:
+		return "200601"
+	case ShardYearly:
+		return "2006"
+	}
+	panic(fmt.Errorf("orm.ShardPeriod wrong period %d", p))
+}
+
+// truncate return the start of period t in
+func (p ShardPeriod) truncate(t time.Time) time.Time {
+	t = t.In(DefaultTimeLoc)
+	switch p {
+	case ShardDaily:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, DefaultTimeLoc)
+	case ShardMonthly:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, DefaultTimeLoc)
+	default:
+		return time.Date(t.Year(), 1, 1, 0, 0, 0, 0, DefaultTimeLoc)
+	}
+}
+
+// add return the start of n periods after the period t in
+func (p ShardPeriod) add(t time.Time, n int) time.Time {
+	t = p.truncate(t)
+	switch p {
+	case ShardDaily:
+		return t.AddDate(0, 0, n)
+	case ShardMonthly:
+		return t.AddDate(0, n, 0)
+	default:
+		return t.AddDate(n, 0, 0)
+	}
+}
+
+// timeStrategy shard by the period of time value
+type timeStrategy struct {
+	period ShardPeriod
+	start  time.Time
+}
+
+// ByTime return the strategy that shards the table by period of time value in DefaultTimeLoc,
+// the first shard is the period of start, and Suffixes return the shards from start to now.
+// for example, orm.ByTime(orm.ShardMonthly, start) store the log of 2021-03-05 in table `log_202103`.
+func ByTime(period ShardPeriod, start time.Time) ShardStrategy {
+	period.layout()
+	return &timeStrategy{period: period, start: period.truncate(start)}
+}
+
+func (s *timeStrategy) Suffix(value interface{}) (string, error) {
+	switch t := value.(type) {
+	case time.Time:
+		return s.period.truncate(t).Format(s.period.layout()), nil
+	case *time.Time:
+		if t != nil {
+			return s.period.truncate(*t).Format(s.period.layout()), nil
+		}
+	}
+	return "", fmt.Errorf("shard key value %v is not time", value)
+}
+
+func (s *timeStrategy) Suffixes() []string {
+	return s.suffixesBetween(s.start, time.Now())
+}
+
+// suffixesBetween return the suffixes of periods from start to end
+func (s *timeStrategy) suffixesBetween(start, end time.Time) []string {
+	var suffixes []string
+	end = s.period.truncate(end)
+	for t := s.period.truncate(start); !t.After(end); t = s.period.add(t, 1) {
+		suffixes = append(suffixes, t.Format(s.period.layout()))
+	}
+	return suffixes
+}
+
+// parseSuffix return the start time of period of suffix
+func (s *timeStrategy) parseSuffix(suffix string) (time.Time, bool) {
+	t, err := time.ParseInLocation(s.period.layout(), suffix, DefaultTimeLoc)
+	if err != nil || t.Format(s.period.layout()) != suffix {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// dbShardStrategy route the tables of strategy to databases
+type dbShardStrategy struct {
+	ShardStrategy
+	dbs map[string]string
+}
+
+// ShardDBs return the strategy that routes the tables of strategy to databases by the map from table suffix to database name,
+// all the suffixes of strategy must be mapped. for example:
+//	orm.RegisterShardedModel("default", new(Order), "UserID", orm.ShardDBs(orm.HashMod(4), map[string]string{
+//		"0": "cluster1", "1": "cluster1", "2": "cluster2", "3": "cluster2",
+//	}))
+func ShardDBs(strategy ShardStrategy, dbs map[string]string) DBShardStrategy {
+	s := &dbShardStrategy{ShardStrategy: strategy, dbs: make(map[string]string, len(dbs))}
+	for suffix, db := range dbs {
+		s.dbs[suffix] = db
+	}
+	for _, suffix := range strategy.Suffixes() {
+		if s.dbs[suffix] == "" {
+			panic(fmt.Errorf("orm.ShardDBs database of suffix `%s` not specified", suffix))
+		}
+	}
+	return s
+}
+
+func (s *dbShardStrategy) DB(suffix string) string {
+	return s.dbs[suffix]
+}
+
+// uniqueSuffixes return the suffixes without duplicates in order of first appearance
+func uniqueSuffixes(n int, suffix func(i int) string) []string {
+	suffixes := make([]string, 0, n)
+	seen := make(map[string]bool, n)
+	for i := 0; i < n; i++ {
+		s := suffix(i)
+		if !seen[s] {
+			seen[s] = true
+			suffixes = append(suffixes, s)
+		}
+	}
+	return suffixes
+}
+
+// toInt64 convert the integer value to int64
+func toInt64(value interface{}) (int64, bool) {
+	val := reflect.Indirect(reflect.ValueOf(value))
+	switch val.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return val.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(val.Uint()), true
+	}
+	return 0, false
+}
+
+// getShardSuffix return the table suffix of the shard key value
+func (mi *modelInfo) getShardSuffix(value interface{}) (string, error) {
+	suffix, err := mi.shardStrategy.Suffix(value)
+	if err != nil {
+		return "", fmt.Errorf("<Ormer> table %s: %v", mi.table, err)
+	}
+	return suffix, nil
+}
+
+// getShardDB return the database name of table suffix, empty if the model is not sharded across databases
+func (mi *modelInfo) getShardDB(suffix string) string {
+	if suffix == "" {
+		return ""
+	}
+	if s, ok := mi.shardStrategy.(DBShardStrategy); ok {
+		return s.DB(suffix)
+	}
+	return ""
+}
+
+// getShardSuffixByCond return the table suffix derived from the equality or in filter of shard key.
+// it's not derived if the shard key is not filtered or the condition has OR.
+// if the values of shard key are in more than one shard, ErrMultiShards is returned.
+func (mi *modelInfo) getShardSuffixByCond(cond *Condition) (string, error) {
+	if mi.shardStrategy == nil {
+		return "", nil
+	}
+
+	values, ok := cond.getShardValues(mi)
+	if !ok || len(values) == 0 {
+		return "", nil
+	}
+
+	suffix, err := mi.getShardSuffix(values[0])
+	if err != nil {
+		return "", err
+	}
+	for _, value := range values[1:] {
+		s, err := mi.getShardSuffix(value)
+		if err != nil {
+			return "", err
+		}
+		if s != suffix {
+			return "", ErrMultiShards(mi.shardField.name, suffix, s)
+		}
+	}
+	return suffix, nil
+}
+
+// getShardValues return the values of shard key in the equality or in filters joined by AND
+func (c *Condition) getShardValues(mi *modelInfo) ([]interface{}, bool) {
+	if c == nil {
+		return nil, false
+	}
+	for _, p := range c.params {
+		if p.isOr {
+			return nil, false
+		}
+	}
+
+	for _, p := range c.params {
+		if p.isNot {
+			continue
+		}
+		if p.isCond {
+			if values, ok := p.cond.getShardValues(mi); ok {
+				return values, true
+			}
+			continue
+		}
+		if p.isRaw || p.isTuple {
+			continue
+		}
+
+		fi, operator, ok := mi.parseExprs(p.exprs)
+		if !ok || fi != mi.shardField || len(p.exprs) > 2 {
+			continue
+		}
+
+		var args []interface{}
+		switch operator {
+		case "exact", "eq":
+			if len(p.args) != 1 {
+				continue
+			}
+			args = p.args
+		case "in":
+			args = p.args
+			if len(args) == 1 {
+				if isSubquery(args[0]) {
+					continue
+				}
+				args = c.flatArgs(args[0])
+			}
+		default:
+			continue
+		}
+
+		values := make([]interface{}, 0, len(args))
+		for _, arg := range args {
+			value, ok := mi.shardField.convertValue(arg)
+			if !ok {
+				return nil, false
+			}
+			values = append(values, value)
+		}
+		return values, true
+	}
+	return nil, false
+}
+
+// convertValue convert the filter value to the type of field, so the value is sharded like the field
+func (fi *fieldInfo) convertValue(value interface{}) (interface{}, bool) {
+	switch value.(type) {
+	case nil, Expr:
+		return nil, false
+	}
+
+	typ := fi.sf.Type
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	val := reflect.Indirect(reflect.ValueOf(value))
+	if !val.IsValid() {
+		return nil, false
+	}
+	if val.Type() == typ {
+		return val.Interface(), true
+	}
+	if isIntegerKind(val.Kind()) && isIntegerKind(typ.Kind()) {
+		return val.Convert(typ).Interface(), true
+	}
+	if val.Kind() == reflect.String && typ.Kind() == reflect.String {
+		return val.Convert(typ).Interface(), true
+	}
+	return nil, false
+}
+
+func isIntegerKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
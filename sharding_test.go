@@ -0,0 +1,299 @@
+package orm
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/std0d9k81/orm/sqlbuilder"
+	"github.com/stretchr/testify/require"
+)
+
+type shardedOrder struct {
+	ID     int64  `orm:"column(id);pk;auto"`
+	UserID int64  `orm:"column(user_id)"`
+	Region string `orm:"column(region)"`
+}
+
+func TestShardStrategy(t *testing.T) {
+	hash := HashMod(4)
+	for value, expected := range map[interface{}]string{int64(35): "3", 8: "0", uint8(5): "1", int32(-3): "1"} {
+		suffix, err := hash.Suffix(value)
+		require.NoError(t, err)
+		require.Equal(t, expected, suffix, "hash mod of %v", value)
+	}
+	suffix, err := hash.Suffix("user")
+	require.NoError(t, err)
+	require.Contains(t, hash.Suffixes(), suffix)
+	require.Equal(t, []string{"0", "1", "2", "3"}, hash.Suffixes())
+
+	ranges := Range(ShardRange{Start: 100, End: 200, Suffix: "b"}, ShardRange{Start: 0, End: 100, Suffix: "a"},
+		ShardRange{Start: 300, End: 400, Suffix: "a"})
+	for value, expected := range map[int64]string{0: "a", 99: "a", 100: "b", 199: "b", 350: "a"} {
+		suffix, err := ranges.Suffix(value)
+		require.NoError(t, err)
+		require.Equal(t, expected, suffix, "range of %v", value)
+	}
+	for _, value := range []interface{}{int64(-1), 200, 400, "1"} {
+		_, err := ranges.Suffix(value)
+		require.Error(t, err, "range of %v", value)
+	}
+	require.Equal(t, []string{"a", "b"}, ranges.Suffixes())
+	require.Panics(t, func() {
+		Range(ShardRange{Start: 0, End: 100, Suffix: "a"}, ShardRange{Start: 50, End: 150, Suffix: "b"})
+	})
+	require.Panics(t, func() { Range(ShardRange{Start: 100, End: 100, Suffix: "a"}) })
+
+	lookup := Lookup(map[string]string{"cn": "asia", "jp": "asia", "us": "america"})
+	suffix, err = lookup.Suffix("jp")
+	require.NoError(t, err)
+	require.Equal(t, "asia", suffix)
+	_, err = lookup.Suffix("uk")
+	require.Error(t, err)
+	require.Equal(t, []string{"asia", "america"}, lookup.Suffixes())
+}
+
+func TestShardSuffix(t *testing.T) {
+	mi := newModelInfo(reflect.ValueOf(&shardedOrder{}))
+	mi.table = "order"
+	mi.sharded = true
+	mi.shardField = mi.getFieldInfo("UserID")
+	mi.shardStrategy = HashMod(4)
+
+	table, err := mi.getTableByInd(reflect.ValueOf(&shardedOrder{UserID: 35}).Elem())
+	require.NoError(t, err)
+	require.Equal(t, "order_3", table)
+	suffix, err := mi.getTableSuffix(reflect.ValueOf(shardedOrder{UserID: 6}))
+	require.NoError(t, err)
+	require.Equal(t, "2", suffix)
+
+	cases := map[string]*Condition{
+		"3": NewCondition().And("UserID", 35),
+		"1": NewCondition().And("ID__gt", 10).And("user_id__in", []int{1, 5, 9}),
+		"2": NewCondition().And("ID", 1).AndCond(NewCondition().And("UserID__eq", uint(6))),
+		"0": NewCondition().And("UserID__in", 4, int64(8)),
+	}
+	for expected, cond := range cases {
+		suffix, err = mi.getShardSuffixByCond(cond)
+		require.NoError(t, err)
+		require.Equal(t, expected, suffix)
+	}
+
+	for _, cond := range []*Condition{
+		nil,
+		NewCondition().And("ID", 35),
+		NewCondition().And("UserID__gt", 35),
+		NewCondition().AndNot("UserID", 35),
+		NewCondition().And("UserID", 35).Or("ID", 1),
+		NewCondition().AndCond(NewCondition().And("UserID", 35).Or("UserID", 36)),
+		NewCondition().And("UserID", F("ID")),
+		NewCondition().And("UserID", "35"),
+	} {
+		suffix, err = mi.getShardSuffixByCond(cond)
+		require.NoError(t, err)
+		require.Equal(t, "", suffix)
+	}
+
+	qs := &querySetter{mi: mi, cond: NewCondition().And("UserID", 35)}
+	suffix, err = qs.getTableSuffix()
+	require.NoError(t, err)
+	require.Equal(t, "3", suffix)
+	suffix, err = qs.WithSuffix("1").(*querySetter).getTableSuffix()
+	require.NoError(t, err)
+	require.Equal(t, "1", suffix)
+
+	qs = &querySetter{mi: mi, cond: NewCondition().And("UserID__in", 1, 2)}
+	_, err = qs.routeDB()
+	require.EqualError(t, err, "<QuerySeter> values of shard key `UserID` are in more than one shard: 1, 2, use AllShards or WithSuffix")
+}
+
+func TestShardSuffixError(t *testing.T) {
+	mi := newModelInfo(reflect.ValueOf(&shardedOrder{}))
+	mi.table = "order"
+	mi.sharded = true
+	mi.shardField = mi.getFieldInfo("UserID")
+	mi.shardStrategy = Lookup(map[string]string{"1": "a", "2": "b"})
+
+	_, err := mi.getTableByInd(reflect.ValueOf(shardedOrder{UserID: 3}))
+	require.Error(t, err, "key not in lookup table")
+	_, _, err = mi.groupBySuffix(reflect.ValueOf([]shardedOrder{{UserID: 1}, {UserID: 3}}))
+	require.Error(t, err)
+	_, err = (&orm{}).routeModel(mi, reflect.ValueOf(shardedOrder{UserID: 3}))
+	require.Error(t, err)
+	_, err = mi.getShardSuffixByCond(NewCondition().And("UserID", 3))
+	require.Error(t, err)
+
+	mi.shardStrategy = ByTime(ShardMonthly, time.Now())
+	_, err = mi.getShardSuffix((*time.Time)(nil))
+	require.Error(t, err, "nil time")
+}
+
+func TestShardSuffixMissing(t *testing.T) {
+	mi := newModelInfo(reflect.ValueOf(&shardedOrder{}))
+	mi.table = "order"
+	mi.sharded = true
+	mi.shardField = mi.getFieldInfo("UserID")
+	mi.shardStrategy = HashMod(4)
+
+	for _, cond := range []*Condition{
+		nil,
+		NewCondition().And("ID", 1),
+		NewCondition().And("UserID", 35).Or("ID", 1),
+	} {
+		qs := &querySetter{mi: mi, cond: cond}
+		_, err := qs.routeDB()
+		require.Equal(t, ErrNoTableSuffix("order"), err)
+		_, err = qs.Update(Params{"Region": "cn"})
+		require.Equal(t, ErrNoTableSuffix("order"), err)
+		_, err = qs.Delete()
+		require.Equal(t, ErrNoTableSuffix("order"), err)
+		_, err = mi.getSelectBuilder(qs, cond, nil)
+		require.Equal(t, ErrNoTableSuffix("order"), err)
+	}
+
+	// the sharded QuerySetter used as a subquery
+	outer := newModelInfo(reflect.ValueOf(&Person{}))
+	outer.table = "person"
+	sub := &querySetter{mi: mi, cond: NewCondition().And("Region", "cn")}
+	for _, cond := range []*Condition{
+		NewCondition().And("ID__in", sub.Select("UserID")),
+		NewCondition().AndCond(NewCondition().And("__exists", sub)),
+	} {
+		_, err := (&querySetter{mi: outer, cond: cond}).routeDB()
+		require.Equal(t, ErrNoTableSuffix("order"), err)
+	}
+
+	qs := &querySetter{mi: outer, cond: NewCondition().And("ID__in", sub.WithSuffix("1").Select("UserID"))}
+	query, _ := buildQuery(mustBuilder(outer.getSelectBuilder(qs, qs.cond, []string{"ID"})), false)
+	require.Equal(t, "SELECT `id` FROM `person` WHERE `id` IN (SELECT `user_id` FROM `order_1` WHERE `region` = ?)", query)
+}
+
+func TestShardDB(t *testing.T) {
+	mi := newModelInfo(reflect.ValueOf(&shardedOrder{}))
+	mi.table = "order"
+	mi.sharded = true
+	mi.shardField = mi.getFieldInfo("UserID")
+	mi.shardStrategy = ShardDBs(HashMod(4), map[string]string{"0": "default", "1": "default", "2": "orm_test2", "3": "orm_test2"})
+
+	suffix, err := mi.getTableSuffix(reflect.ValueOf(shardedOrder{UserID: 6}))
+	require.NoError(t, err)
+	require.Equal(t, "orm_test2", mi.getShardDB(suffix))
+	require.Equal(t, "default", mi.getShardDB("1"))
+	require.Equal(t, "", mi.getShardDB(""))
+	require.Equal(t, "", newModelInfo(reflect.ValueOf(&shardedOrder{})).getShardDB("1"), "not sharded across databases")
+
+	require.Panics(t, func() { ShardDBs(HashMod(2), map[string]string{"0": "default"}) }, "suffix 1 not mapped")
+
+	default2, _ := dbCache.get("orm_test2")
+	o := &orm{dbName: "default", db: dbCache.getDefault().DB}
+	db, err := o.routeDB(mi, "2")
+	require.NoError(t, err)
+	require.Equal(t, default2.DB, db, "routed to orm_test2")
+	db, err = o.routeDB(mi, "1")
+	require.NoError(t, err)
+	require.Equal(t, o.db, db, "routed to current database")
+	require.Equal(t, "default", o.dbName, "current database not changed")
+
+	o.isTx = true
+	_, err = o.routeDB(mi, "3")
+	require.Equal(t, ErrCrossDBTransaction, err)
+	_, err = (&querySetter{orm: o, mi: mi, cond: NewCondition().And("UserID", 7)}).Count()
+	require.Equal(t, ErrCrossDBTransaction, err)
+	db, err = o.routeDB(mi, "0")
+	require.NoError(t, err)
+	require.Equal(t, o.db, db)
+}
+
+func TestGroupBySuffix(t *testing.T) {
+	mi := newModelInfo(reflect.ValueOf(&shardedOrder{}))
+	mi.table = "order"
+	mi.sharded = true
+	mi.shardField = mi.getFieldInfo("UserID")
+	mi.shardStrategy = HashMod(4)
+
+	orders := []shardedOrder{{UserID: 5}, {UserID: 2}, {UserID: 9}, {UserID: 6}, {UserID: 1}}
+	suffixes, groups, err := mi.groupBySuffix(reflect.ValueOf(orders))
+	require.NoError(t, err)
+	require.Equal(t, []string{"1", "2"}, suffixes)
+	require.Equal(t, []shardedOrder{{UserID: 5}, {UserID: 9}, {UserID: 1}}, groups["1"].Interface())
+	require.Equal(t, []shardedOrder{{UserID: 2}, {UserID: 6}}, groups["2"].Interface())
+
+	suffixes, groups, err = newModelInfo(reflect.ValueOf(&shardedOrder{})).groupBySuffix(reflect.ValueOf([2]*shardedOrder{{}, {}}))
+	require.NoError(t, err)
+	require.Equal(t, []string{""}, suffixes)
+	require.Equal(t, 2, groups[""].Len())
+}
+
+func TestCreateShardSQL(t *testing.T) {
+	mi := newModelInfo(reflect.ValueOf(&indexModel{}))
+	mi.table = "index_model"
+	mi.sharded = true
+
+	require.Equal(t, []string{"CREATE TABLE IF NOT EXISTS `index_model_1` LIKE `index_model`"}, mi.getCreateShardSQL(sqlbuilder.MySQL, "1", true))
+	require.Equal(t, []string{`CREATE TABLE IF NOT EXISTS "index_model_1" (LIKE "index_model" INCLUDING ALL)`},
+		mi.getCreateShardSQL(sqlbuilder.PostgreSQL, "1", true))
+
+	queries := mi.getCreateShardSQL(sqlbuilder.MySQL, "1", false)
+	require.Len(t, queries, 4)
+	require.Contains(t, queries[0], "CREATE TABLE IF NOT EXISTS `index_model_1` (\n")
+	require.Equal(t, "CREATE INDEX `idx_index_model_1_status` ON `index_model_1` (`status`)", queries[1])
+
+	// SQLite doesn't support LIKE
+	queries = mi.getCreateShardSQL(sqlbuilder.SQLite, "1", true)
+	require.Equal(t, mi.getCreateTableSQLOf(sqlbuilder.SQLite, "index_model_1"), queries)
+	require.Contains(t, queries[0], `CREATE TABLE IF NOT EXISTS "index_model_1" (`)
+}
+
+func TestGroupShardsByDB(t *testing.T) {
+	mi := newModelInfo(reflect.ValueOf(&shardedOrder{}))
+	mi.db = "default"
+	mi.shardStrategy = HashMod(4)
+
+	dbNames, dbShards := mi.groupShardsByDB([]string{"0", "1"})
+	require.Equal(t, []string{"default"}, dbNames)
+	require.Equal(t, map[string][]string{"default": {"0", "1"}}, dbShards)
+
+	// the database without current shards is scanned for the expired shards
+	mi.shardStrategy = ShardDBs(HashMod(4), map[string]string{"0": "cluster2", "1": "cluster2", "2": "cluster1", "3": "cluster1"})
+	dbNames, dbShards = mi.groupShardsByDB([]string{"1", "0"})
+	require.Equal(t, []string{"cluster2", "cluster1"}, dbNames)
+	require.Equal(t, map[string][]string{"cluster2": {"1", "0"}, "cluster1": nil}, dbShards)
+}
+
+func TestTimeShardStrategy(t *testing.T) {
+	start := time.Date(2021, 11, 15, 8, 0, 0, 0, DefaultTimeLoc)
+	monthly := ByTime(ShardMonthly, start).(*timeStrategy)
+
+	suffix, err := monthly.Suffix(time.Date(2022, 2, 28, 23, 59, 0, 0, DefaultTimeLoc))
+	require.NoError(t, err)
+	require.Equal(t, "202202", suffix)
+	_, err = monthly.Suffix(20220228)
+	require.Error(t, err)
+
+	require.Equal(t, []string{"202111", "202112", "202201", "202202"},
+		monthly.suffixesBetween(start, time.Date(2022, 2, 1, 0, 0, 0, 0, DefaultTimeLoc)))
+	require.Equal(t, monthly.Suffixes()[len(monthly.Suffixes())-1], time.Now().In(DefaultTimeLoc).Format("200601"))
+
+	begin, ok := monthly.parseSuffix("202112")
+	require.True(t, ok)
+	require.Equal(t, time.Date(2021, 12, 1, 0, 0, 0, 0, DefaultTimeLoc), begin)
+	for _, suffix := range []string{"2021", "202113", "2021120", "bak"} {
+		_, ok = monthly.parseSuffix(suffix)
+		require.False(t, ok, "parse suffix %s", suffix)
+	}
+
+	daily := ByTime(ShardDaily, start)
+	now := time.Now()
+	suffix, err = daily.Suffix(&now)
+	require.NoError(t, err)
+	require.Equal(t, now.In(DefaultTimeLoc).Format("20060102"), suffix)
+
+	yearly := ByTime(ShardYearly, start).(*timeStrategy)
+	require.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, DefaultTimeLoc), yearly.period.add(start, 3))
+
+	mi := newModelInfo(reflect.ValueOf(&shardedOrder{}))
+	mi.shardStrategy = &dbShardStrategy{ShardStrategy: monthly}
+	require.Equal(t, monthly, mi.getTimeStrategy())
+	mi.shardStrategy = HashMod(2)
+	require.Nil(t, mi.getTimeStrategy())
+}
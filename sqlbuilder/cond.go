@@ -4,6 +4,7 @@
 package sqlbuilder
 
 import (
+	"bytes"
 	"fmt"
 	"strings"
 )
@@ -33,6 +34,15 @@ func (c *Cond) NE(field string, value interface{}) string {
 	return c.NotEqual(field, value)
 }
 
+// IsDistinctFrom represents the NULL-safe "field != value".
+// It's "NOT (field <=> value)" in MySQL and "field IS DISTINCT FROM value" in PostgreSQL.
+func (c *Cond) IsDistinctFrom(field string, value interface{}) string {
+	if c.Args.Flavor == PostgreSQL {
+		return fmt.Sprintf("%v IS DISTINCT FROM %v", Escape(field), c.Args.Add(value))
+	}
+	return fmt.Sprintf("NOT (%v <=> %v)", Escape(field), c.Args.Add(value))
+}
+
 // GreaterThan represents "field > value".
 func (c *Cond) GreaterThan(field string, value interface{}) string {
 	return fmt.Sprintf("%v > %v", Escape(field), c.Args.Add(value))
@@ -74,7 +84,12 @@ func (c *Cond) LE(field string, value interface{}) string {
 }
 
 // In represents "field IN (value...)".
+// It represents the false predicate "0 = 1" if value is empty.
 func (c *Cond) In(field string, value ...interface{}) string {
+	if len(value) == 0 {
+		return "0 = 1"
+	}
+
 	vs := make([]string, 0, len(value))
 
 	for _, v := range value {
@@ -85,7 +100,12 @@ func (c *Cond) In(field string, value ...interface{}) string {
 }
 
 // NotIn represents "field NOT IN (value...)".
+// It represents the true predicate "1 = 1" if value is empty.
 func (c *Cond) NotIn(field string, value ...interface{}) string {
+	if len(value) == 0 {
+		return "1 = 1"
+	}
+
 	vs := make([]string, 0, len(value))
 
 	for _, v := range value {
@@ -115,6 +135,24 @@ func (c *Cond) NotLikeBinary(field string, value interface{}) string {
 	return fmt.Sprintf("%v NOT LIKE BINARY %v", Escape(field), c.Args.Add(value))
 }
 
+// Regexp represents the case-sensitive regular expression matching.
+// It's "field REGEXP BINARY value" in MySQL and "field ~ value" in PostgreSQL.
+func (c *Cond) Regexp(field string, value interface{}) string {
+	if c.Args.Flavor == PostgreSQL {
+		return fmt.Sprintf("%v ~ %v", Escape(field), c.Args.Add(value))
+	}
+	return fmt.Sprintf("%v REGEXP BINARY %v", Escape(field), c.Args.Add(value))
+}
+
+// IRegexp represents the case-insensitive regular expression matching.
+// It's "field REGEXP value" in MySQL and "field ~* value" in PostgreSQL.
+func (c *Cond) IRegexp(field string, value interface{}) string {
+	if c.Args.Flavor == PostgreSQL {
+		return fmt.Sprintf("%v ~* %v", Escape(field), c.Args.Add(value))
+	}
+	return fmt.Sprintf("%v REGEXP %v", Escape(field), c.Args.Add(value))
+}
+
 // IsNull represents "field IS NULL".
 func (c *Cond) IsNull(field string) string {
 	return fmt.Sprintf("%v IS NULL", Escape(field))
@@ -125,6 +163,16 @@ func (c *Cond) IsNotNull(field string) string {
 	return fmt.Sprintf("%v IS NOT NULL", Escape(field))
 }
 
+// IsEmpty represents "(field IS NULL OR field = '')".
+func (c *Cond) IsEmpty(field string) string {
+	return fmt.Sprintf("(%v IS NULL OR %v = '')", Escape(field), Escape(field))
+}
+
+// IsNotEmpty represents "(field IS NOT NULL AND field <> '')".
+func (c *Cond) IsNotEmpty(field string) string {
+	return fmt.Sprintf("(%v IS NOT NULL AND %v <> '')", Escape(field), Escape(field))
+}
+
 // Between represents "field BETWEEN lower AND upper".
 func (c *Cond) Between(field string, lower, upper interface{}) string {
 	return fmt.Sprintf("%v BETWEEN %v AND %v", Escape(field), c.Args.Add(lower), c.Args.Add(upper))
@@ -135,6 +183,143 @@ func (c *Cond) NotBetween(field string, lower, upper interface{}) string {
 	return fmt.Sprintf("%v NOT BETWEEN %v AND %v", Escape(field), c.Args.Add(lower), c.Args.Add(upper))
 }
 
+// Exists represents "EXISTS (subquery)".
+func (c *Cond) Exists(subquery interface{}) string {
+	return fmt.Sprintf("EXISTS (%v)", c.Args.Add(subquery))
+}
+
+// NotExists represents "NOT EXISTS (subquery)".
+func (c *Cond) NotExists(subquery interface{}) string {
+	return fmt.Sprintf("NOT EXISTS (%v)", c.Args.Add(subquery))
+}
+
+// TupleGreaterThan represents "(field1, field2, ...) > (value1, value2, ...)".
+func (c *Cond) TupleGreaterThan(fields []string, value ...interface{}) string {
+	return c.tupleCompare(fields, ">", value)
+}
+
+// TupleLessThan represents "(field1, field2, ...) < (value1, value2, ...)".
+func (c *Cond) TupleLessThan(fields []string, value ...interface{}) string {
+	return c.tupleCompare(fields, "<", value)
+}
+
+func (c *Cond) tupleCompare(fields []string, op string, value []interface{}) string {
+	vs := make([]string, 0, len(value))
+
+	for _, v := range value {
+		vs = append(vs, c.Args.Add(v))
+	}
+
+	return fmt.Sprintf("(%v) %v (%v)", strings.Join(EscapeAll(fields...), ", "), op, strings.Join(vs, ", "))
+}
+
+// Year represents the year of date field, e.g. "YEAR(field)".
+// The result is not escaped, it's used as the field of other conditions, e.g. c.E(c.Year("created"), 2020).
+func (c *Cond) Year(field string) string {
+	return c.extract("YEAR", field)
+}
+
+// Month represents the month of date field, e.g. "MONTH(field)".
+// The result is not escaped, it's used as the field of other conditions.
+func (c *Cond) Month(field string) string {
+	return c.extract("MONTH", field)
+}
+
+// Day represents the day of month of date field, e.g. "DAY(field)".
+// The result is not escaped, it's used as the field of other conditions.
+func (c *Cond) Day(field string) string {
+	return c.extract("DAY", field)
+}
+
+// Date represents the date part of datetime field, e.g. "DATE(field)".
+// The result is not escaped, it's used as the field of other conditions.
+func (c *Cond) Date(field string) string {
+	if c.Args.Flavor == PostgreSQL {
+		return fmt.Sprintf("CAST(%v AS DATE)", field)
+	}
+	return fmt.Sprintf("DATE(%v)", field)
+}
+
+// JSONExtract represents the value at path of json field.
+// It's "JSON_EXTRACT(field, '$.a.b[0]')" in MySQL and "(CAST(field AS JSONB) -> 'a' -> 'b' ->> 0)" in PostgreSQL.
+// Path keys are inlined in sql, so they must be identifiers or array indexes, otherwise it panics.
+// The result is not escaped, it's used as the field of other conditions.
+func (c *Cond) JSONExtract(field string, path ...string) string {
+	if len(path) == 0 {
+		panic(fmt.Errorf("Cond.JSONExtract: path cannot empty"))
+	}
+
+	buf := &bytes.Buffer{}
+	if c.Args.Flavor == PostgreSQL {
+		fmt.Fprintf(buf, "(CAST(%v AS JSONB)", field)
+		for i, key := range path {
+			op := "->"
+			if i == len(path)-1 {
+				op = "->>"
+			}
+			if isJSONIndex(key) {
+				fmt.Fprintf(buf, " %v %v", op, key)
+			} else {
+				fmt.Fprintf(buf, " %v '%v'", op, checkJSONKey(key))
+			}
+		}
+		buf.WriteString(")")
+		return buf.String()
+	}
+
+	fmt.Fprintf(buf, "JSON_EXTRACT(%v, '$", field)
+	for _, key := range path {
+		if isJSONIndex(key) {
+			fmt.Fprintf(buf, "[%v]", key)
+		} else {
+			fmt.Fprintf(buf, ".%v", checkJSONKey(key))
+		}
+	}
+	buf.WriteString("')")
+	return buf.String()
+}
+
+// JSONContains represents the json field contains the json document value.
+// It's "JSON_CONTAINS(field, value)" in MySQL and "CAST(field AS JSONB) @> CAST(value AS JSONB)" in PostgreSQL.
+func (c *Cond) JSONContains(field string, value interface{}) string {
+	if c.Args.Flavor == PostgreSQL {
+		return fmt.Sprintf("CAST(%v AS JSONB) @> CAST(%v AS JSONB)", Escape(field), c.Args.Add(value))
+	}
+	return fmt.Sprintf("JSON_CONTAINS(%v, %v)", Escape(field), c.Args.Add(value))
+}
+
+func isJSONIndex(key string) bool {
+	if key == "" {
+		return false
+	}
+	for _, r := range key {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func checkJSONKey(key string) string {
+	for i, r := range key {
+		if r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (i > 0 && r >= '0' && r <= '9') {
+			continue
+		}
+		panic(fmt.Errorf("Cond.JSONExtract: invalid json path key `%v`", key))
+	}
+	if key == "" {
+		panic(fmt.Errorf("Cond.JSONExtract: json path key cannot empty"))
+	}
+	return key
+}
+
+func (c *Cond) extract(part, field string) string {
+	if c.Args.Flavor == PostgreSQL {
+		return fmt.Sprintf("EXTRACT(%v FROM %v)", part, field)
+	}
+	return fmt.Sprintf("%v(%v)", part, field)
+}
+
 // Or represents OR logic like "expr1 OR expr2 OR expr3".
 func (c *Cond) Or(orExpr ...string) string {
 	return fmt.Sprintf("(%v)", strings.Join(orExpr, " OR "))
@@ -31,6 +31,23 @@ func TestCond(t *testing.T) {
 		"$$a NOT BETWEEN $0 AND $1":   func() string { return newTestCond().NotBetween("$a", 123, 456) },
 		"(1 = 1 OR 2 = 2 OR 3 = 3)":   func() string { return newTestCond().Or("1 = 1", "2 = 2", "3 = 3") },
 		"(1 = 1 AND 2 = 2 AND 3 = 3)": func() string { return newTestCond().And("1 = 1", "2 = 2", "3 = 3") },
+		"($$a, b) > ($0, $1)":         func() string { return newTestCond().TupleGreaterThan([]string{"$a", "b"}, 1, 2) },
+		"($$a, b) < ($0, $1)":         func() string { return newTestCond().TupleLessThan([]string{"$a", "b"}, 1, 2) },
+		"EXISTS ($0)":                 func() string { return newTestCond().Exists(NewSelectBuilder().Select("1").From("t")) },
+		"NOT EXISTS ($0)":             func() string { return newTestCond().NotExists(NewSelectBuilder().Select("1").From("t")) },
+		"0 = 1":                       func() string { return newTestCond().In("$a") },
+		"1 = 1":                       func() string { return newTestCond().NotIn("$a") },
+		"NOT ($$a <=> $0)":            func() string { return newTestCond().IsDistinctFrom("$a", 1) },
+		"$$a REGEXP BINARY $0":        func() string { return newTestCond().Regexp("$a", "^a") },
+		"$$a REGEXP $0":               func() string { return newTestCond().IRegexp("$a", "^a") },
+		"(a IS NULL OR a = '')":       func() string { return newTestCond().IsEmpty("a") },
+		"(a IS NOT NULL AND a <> '')": func() string { return newTestCond().IsNotEmpty("a") },
+		"YEAR(a) = $0":                func() string { c := newTestCond(); return c.E(c.Year("a"), 2020) },
+		"MONTH(a)":                    func() string { return newTestCond().Month("a") },
+		"DAY(a)":                      func() string { return newTestCond().Day("a") },
+		"DATE(a)":                     func() string { return newTestCond().Date("a") },
+		"JSON_EXTRACT(a, '$.b[0].c')": func() string { return newTestCond().JSONExtract("a", "b", "0", "c") },
+		"JSON_CONTAINS($$a, $0)":      func() string { return newTestCond().JSONContains("$a", `"vip"`) },
 		"$0": func() string { return newTestCond().Var(123) },
 	}
 
@@ -46,3 +63,46 @@ func newTestCond() *Cond {
 		Args: &Args{},
 	}
 }
+
+func TestCondPostgreSQL(t *testing.T) {
+	cases := map[string]func() string{
+		"$$a IS DISTINCT FROM $0":   func() string { return newTestPostgreSQLCond().IsDistinctFrom("$a", 1) },
+		"$$a ~ $0":                  func() string { return newTestPostgreSQLCond().Regexp("$a", "^a") },
+		"$$a ~* $0":                 func() string { return newTestPostgreSQLCond().IRegexp("$a", "^a") },
+		"EXTRACT(YEAR FROM a) = $0": func() string { c := newTestPostgreSQLCond(); return c.E(c.Year("a"), 2020) },
+		"EXTRACT(MONTH FROM a)":     func() string { return newTestPostgreSQLCond().Month("a") },
+		"EXTRACT(DAY FROM a)":       func() string { return newTestPostgreSQLCond().Day("a") },
+		"CAST(a AS DATE)":           func() string { return newTestPostgreSQLCond().Date("a") },
+		"(CAST(a AS JSONB) -> 'b' -> 0 ->> 'c')": func() string {
+			return newTestPostgreSQLCond().JSONExtract("a", "b", "0", "c")
+		},
+		"CAST($$a AS JSONB) @> CAST($0 AS JSONB)": func() string {
+			return newTestPostgreSQLCond().JSONContains("$a", `"vip"`)
+		},
+	}
+
+	for expected, f := range cases {
+		if actual := f(); expected != actual {
+			t.Fatalf("invalid result. [expected:%v] [actual:%v]", expected, actual)
+		}
+	}
+}
+
+func newTestPostgreSQLCond() *Cond {
+	return &Cond{
+		Args: &Args{Flavor: PostgreSQL},
+	}
+}
+
+func TestCondJSONExtractInvalidPath(t *testing.T) {
+	for _, key := range []string{"", "a'b", "a.b", "1a", "a b"} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatalf("invalid json path key `%v` not panic", key)
+				}
+			}()
+			newTestCond().JSONExtract("a", key)
+		}()
+	}
+}
@@ -11,6 +11,7 @@ const (
 
 	MySQL
 	PostgreSQL
+	SQLite
 )
 
 var (
@@ -28,6 +29,8 @@ func (f Flavor) String() string {
 		return "MySQL"
 	case PostgreSQL:
 		return "PostgreSQL"
+	case SQLite:
+		return "SQLite"
 	}
 
 	return "<invalid>"
@@ -65,12 +68,12 @@ func (f Flavor) NewUpdateBuilder() *UpdateBuilder {
 // as table name or field name.
 //
 // * For MySQL, use back quote (`) to quote name;
-// * For PostgreSQL, use double quote (") to quote name.
+// * For PostgreSQL and SQLite, use double quote (") to quote name.
 func (f Flavor) Quote(name string) string {
 	switch f {
 	case MySQL:
 		return fmt.Sprintf("`%v`", name)
-	case PostgreSQL:
+	case PostgreSQL, SQLite:
 		return fmt.Sprintf(`"%v"`, name)
 	}
 
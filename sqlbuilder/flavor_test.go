@@ -13,6 +13,7 @@ func TestFlavor(t *testing.T) {
 		0:          "<invalid>",
 		MySQL:      "MySQL",
 		PostgreSQL: "PostgreSQL",
+		SQLite:     "SQLite",
 	}
 
 	for f, expected := range cases {
@@ -74,6 +74,13 @@ func (sb *SelectBuilder) Select(col ...string) *SelectBuilder {
 	return sb
 }
 
+// SelectVarAs appends "value AS alias" to the columns in SELECT, value is added to args.
+// It's used to select an expression with args, e.g. a Builder. It must be called after Select.
+func (sb *SelectBuilder) SelectVarAs(value interface{}, alias string) *SelectBuilder {
+	sb.selectCols = append(sb.selectCols, fmt.Sprintf("%v AS %v", sb.Var(value), Escape(alias)))
+	return sb
+}
+
 // From sets table names in SELECT.
 func (sb *SelectBuilder) From(table ...string) *SelectBuilder {
 	sb.tables = table
@@ -245,6 +252,18 @@ func (sb *SelectBuilder) BuildWithFlavor(flavor Flavor, initialArg ...interface{
 			buf.WriteString(" OFFSET ")
 			buf.WriteString(strconv.Itoa(sb.offset))
 		}
+	} else if sb.offset >= 0 {
+		// MySQL and SQLite don't support OFFSET without LIMIT, use the max row count or -1 as LIMIT.
+		switch flavor {
+		case PostgreSQL:
+		case SQLite:
+			buf.WriteString(" LIMIT -1")
+		default:
+			buf.WriteString(" LIMIT 18446744073709551615")
+		}
+
+		buf.WriteString(" OFFSET ")
+		buf.WriteString(strconv.Itoa(sb.offset))
 	}
 
 	if sb.forUpdate {
@@ -6,6 +6,7 @@ package sqlbuilder
 import (
 	"database/sql"
 	"fmt"
+	"testing"
 )
 
 func ExampleSelectBuilder() {
@@ -91,3 +92,31 @@ func ExampleSelectBuilder_join() {
 	// SELECT u.id, u.name, c.type, p.nickname FROM user u JOIN contract c ON u.id = c.user_id AND c.status IN (?, ?, ?) RIGHT OUTER JOIN person p ON u.id = p.user_id AND p.surname LIKE ? WHERE u.modified_at > u.created_at + ?
 	// [1 2 5 %Du 86400]
 }
+
+func TestSelectBuilderSelectVarAs(t *testing.T) {
+	sb := PostgreSQL.NewSelectBuilder()
+	sb.Select("id").SelectVarAs(Buildf("score(%v)", "a"), "$score").From("user").Where(sb.E("id", 1))
+
+	if actual, expected := sb.String(), "SELECT id, score($1) AS $score FROM user WHERE id = $2"; actual != expected {
+		t.Fatalf("invalid result. [expected:%v] [actual:%v]", expected, actual)
+	}
+}
+
+func TestSelectBuilderOffsetWithoutLimit(t *testing.T) {
+	sb := NewSelectBuilder()
+	sb.Select("id").From("user").Offset(10)
+
+	if actual, expected := sb.String(), "SELECT id FROM user LIMIT 18446744073709551615 OFFSET 10"; actual != expected {
+		t.Fatalf("invalid result. [expected:%v] [actual:%v]", expected, actual)
+	}
+
+	sql, _ := sb.BuildWithFlavor(PostgreSQL)
+	if expected := "SELECT id FROM user OFFSET 10"; sql != expected {
+		t.Fatalf("invalid result. [expected:%v] [actual:%v]", expected, sql)
+	}
+
+	sql, _ = sb.BuildWithFlavor(SQLite)
+	if expected := "SELECT id FROM user LIMIT -1 OFFSET 10"; sql != expected {
+		t.Fatalf("invalid result. [expected:%v] [actual:%v]", expected, sql)
+	}
+}
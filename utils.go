@@ -1,13 +1,17 @@
 package orm
 
 import (
+	"database/sql/driver"
 	"fmt"
 	"math/big"
 	"reflect"
 	"strconv"
 	"strings"
+	"time"
 )
 
+var timeType = reflect.TypeOf(time.Time{})
+
 // StrTo is the target string
 type StrTo string
 
@@ -184,6 +188,116 @@ func ToInt64(value interface{}) (d int64) {
 	return
 }
 
+// setRawValue convert the value scanned from driver to the kind of ind, and set it to ind.
+// nolint:gocyclo
+func setRawValue(ind reflect.Value, value interface{}) error {
+	if value == nil {
+		ind.Set(reflect.Zero(ind.Type()))
+		return nil
+	}
+
+	if b, ok := value.([]byte); ok {
+		// driver bytes may be reused by the next scan
+		value = append([]byte(nil), b...)
+	}
+
+	if ind.Kind() == reflect.Interface {
+		if b, ok := value.([]byte); ok {
+			value = string(b)
+		}
+		ind.Set(reflect.ValueOf(value))
+		return nil
+	}
+
+	if ind.Type() == timeType {
+		switch v := value.(type) {
+		case time.Time:
+			ind.Set(reflect.ValueOf(v))
+		default:
+			t, err := time.ParseInLocation("2006-01-02 15:04:05", ToStr(v), DefaultTimeLoc)
+			if err != nil {
+				return err
+			}
+			ind.Set(reflect.ValueOf(t))
+		}
+		return nil
+	}
+
+	var (
+		str = StrTo(ToStr(value))
+		err error
+	)
+
+	switch ind.Kind() {
+	case reflect.Bool:
+		var v bool
+		v, err = str.Bool()
+		ind.SetBool(v)
+	case reflect.Int:
+		var v int64
+		v, err = str.Int64()
+		ind.SetInt(v)
+	case reflect.Int8:
+		var v int8
+		v, err = str.Int8()
+		ind.SetInt(int64(v))
+	case reflect.Int16:
+		var v int16
+		v, err = str.Int16()
+		ind.SetInt(int64(v))
+	case reflect.Int32:
+		var v int32
+		v, err = str.Int32()
+		ind.SetInt(int64(v))
+	case reflect.Int64:
+		var v int64
+		v, err = str.Int64()
+		ind.SetInt(v)
+	case reflect.Uint:
+		var v uint64
+		v, err = str.Uint64()
+		ind.SetUint(v)
+	case reflect.Uint8:
+		var v uint8
+		v, err = str.Uint8()
+		ind.SetUint(uint64(v))
+	case reflect.Uint16:
+		var v uint16
+		v, err = str.Uint16()
+		ind.SetUint(uint64(v))
+	case reflect.Uint32:
+		var v uint32
+		v, err = str.Uint32()
+		ind.SetUint(uint64(v))
+	case reflect.Uint64:
+		var v uint64
+		v, err = str.Uint64()
+		ind.SetUint(v)
+	case reflect.Float32:
+		var v float32
+		v, err = str.Float32()
+		ind.SetFloat(float64(v))
+	case reflect.Float64:
+		var v float64
+		v, err = str.Float64()
+		ind.SetFloat(v)
+	case reflect.String:
+		ind.SetString(str.String())
+	case reflect.Slice:
+		if ind.Type().Elem().Kind() != reflect.Uint8 {
+			return fmt.Errorf("cannot convert `%T` to `%v`", value, ind.Type())
+		}
+		ind.SetBytes([]byte(str.String()))
+	default:
+		return fmt.Errorf("cannot convert `%T` to `%v`", value, ind.Type())
+	}
+
+	if err != nil {
+		return fmt.Errorf("cannot convert `%v` to `%v`, %v", value, ind.Type(), err)
+	}
+	return nil
+}
+
 // snake string, XxYy to xx_yy , XxYY to xx_yy
 func snakeString(s string) string {
 	data := make([]byte, 0, len(s)*2)
@@ -273,3 +387,31 @@ func IsEmptyValue(v reflect.Value) bool {
 		}
 	}
 }
+
+// isNullValue return true if v is written as NULL, which is nil or the driver.Valuer of nil, e.g. invalid sql.NullString
+func isNullValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice:
+		if v.IsNil() {
+			return true
+		}
+	}
+	if valuer, ok := v.Interface().(driver.Valuer); ok {
+		value, err := valuer.Value()
+		return err == nil && value == nil
+	}
+	return false
+}
+
+// equalStrings return true if a and b have the same strings in the same order
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -3,6 +3,7 @@ package orm
 import (
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -25,3 +26,33 @@ func TestIsEmptyValue(t *testing.T) {
 	require.True(t, IsEmptyValue(reflect.ValueOf("")))
 	require.True(t, IsEmptyValue(reflect.ValueOf(0)))
 }
+
+func TestSetRawValue(t *testing.T) {
+	var (
+		i64   int64
+		u8    uint8
+		f64   float64
+		str   string
+		b     bool
+		tm    time.Time
+		iface interface{}
+	)
+
+	require.NoError(t, setRawValue(reflect.ValueOf(&i64).Elem(), []byte("-42")))
+	require.Equal(t, int64(-42), i64)
+	require.NoError(t, setRawValue(reflect.ValueOf(&u8).Elem(), int64(200)))
+	require.Equal(t, uint8(200), u8)
+	require.Error(t, setRawValue(reflect.ValueOf(&u8).Elem(), int64(300)))
+	require.NoError(t, setRawValue(reflect.ValueOf(&f64).Elem(), []byte("1.5")))
+	require.Equal(t, 1.5, f64)
+	require.NoError(t, setRawValue(reflect.ValueOf(&str).Elem(), int64(10)))
+	require.Equal(t, "10", str)
+	require.NoError(t, setRawValue(reflect.ValueOf(&b).Elem(), []byte("1")))
+	require.True(t, b)
+	require.NoError(t, setRawValue(reflect.ValueOf(&tm).Elem(), []byte("2019-01-02 03:04:05")))
+	require.Equal(t, 2019, tm.Year())
+	require.NoError(t, setRawValue(reflect.ValueOf(&iface).Elem(), []byte("abc")))
+	require.Equal(t, "abc", iface)
+	require.NoError(t, setRawValue(reflect.ValueOf(&i64).Elem(), nil))
+	require.Equal(t, int64(0), i64)
+}